@@ -2,14 +2,13 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"eve-chaperone/eve"
+	"eve-chaperone/eve/universe"
 	"fmt"
-	"io/fs"
-	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 
 	"github.com/pkg/browser"
@@ -17,51 +16,210 @@ import (
 )
 
 type App struct {
+	Client    *eve.Client
+	Cache     *eve.Cache
+	Vault     eve.Vault
+	Sessions  *eve.SessionManager
+	Refresher *eve.Refresher
+	Login     *eve.LoginFlow
+	Stargates *eve.StargateGraph
+	Jumps     *eve.JumpPlanner
+	Watcher   *eve.LocationWatcher
+	JumpLog   *eve.JumpLog
+
+	// ctxMu guards ctx, which Wails hands over in startup while the
+	// background work may already emit events
+	ctxMu sync.RWMutex
 	ctx   context.Context
-	Cache eve.Cache
+
+	runOnce sync.Once
+	// stop ends the background work started by OnDomReady.
+	stop context.CancelFunc
+
+	vaultMu  sync.Mutex
+	vaultErr error
+
+	killsMu     sync.Mutex
+	killsSystem int64
+	killsCancel context.CancelFunc
 }
 
 type Location struct {
 }
 
+var ErrExportFormat = errors.New("unknown export format")
+
 func NewApp() *App {
-	return &App{
-		Cache: eve.NewCache(),
+	vault, err := newVault()
+
+	// the app still starts so the failure can be shown and the vault
+	// unlocked with its passphrase
+	if err != nil {
+		fmt.Println(err)
+
+		vault = lockedVault{err: err}
+	}
+
+	useStaticData()
+
+	client := newClient()
+	cache := openCache()
+	sessions := eve.NewSessionManager()
+	refresher := eve.NewRefresher(client, sessions)
+	stargates := eve.NewStargateGraph(universe.Default())
+
+	client.Cache = cache
+
+	app := &App{
+		Client:    client,
+		Cache:     cache,
+		Vault:     vault,
+		Sessions:  sessions,
+		Refresher: refresher,
+		Login:     eve.NewLoginFlow(client, callbackPort()),
+		Stargates: stargates,
+		Jumps:     eve.NewJumpPlanner(stargates),
+		Watcher:   eve.NewLocationWatcher(client, sessions, refresher, cache),
+		JumpLog:   openJumpLog(),
+		vaultErr:  err,
+	}
+
+	app.Refresher.OnRefresh = func(session eve.Session) {
+		app.writeCharacters(session.Auth())
+	}
+
+	app.Refresher.OnRevoked = func(string) {
+		app.emitAuth()
+	}
+
+	app.Watcher.OnLocation = func(event eve.LocationEvent) {
+		_, err := app.JumpLog.Record(event.Character, event.Location, time.Now())
+
+		if err != nil {
+			fmt.Println("recording jump log:", err)
+		}
+
+		app.emit(eve.EventLocationChanged, event)
+	}
+
+	app.Watcher.OnKills = func(event eve.KillsEvent) {
+		app.emit(eve.EventKillsUpdated, event)
 	}
+
+	return app
 }
 
 func (a *App) startup(ctx context.Context) {
+	a.ctxMu.Lock()
 	a.ctx = ctx
+	a.ctxMu.Unlock()
 }
 
-func (a *App) CheckAuth() bool {
-	return eve.CheckAuth(a.ctx)
+// context returns the context Wails passed to startup.
+func (a *App) context() context.Context {
+	a.ctxMu.RLock()
+	defer a.ctxMu.RUnlock()
+
+	return a.ctx
+}
+
+// emit sends an event to the frontend, dropping it before startup.
+func (a *App) emit(name string, data ...interface{}) {
+	ctx := a.context()
+
+	if ctx == nil {
+		return
+	}
+
+	runtime.EventsEmit(ctx, name, data...)
 }
 
-func (a *App) OpenAuth() {
-	browser.OpenURL("http://localhost:3003/login")
+func (a *App) shutdown(ctx context.Context) {
+	if a.stop != nil {
+		a.stop()
+	}
 
-	ctx, err := eve.GetAccessToken(a.ctx)
+	err := a.Cache.Close()
 
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println(err)
 	}
 
-	a.ctx = ctx
+	err = a.JumpLog.Close()
+
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (a *App) CheckAuth() bool {
+	return a.Sessions.HasCurrent()
+}
+
+func (a *App) OpenAuth() error {
+	return a.login(eve.RequiredScopes())
+}
+
+// CancelAuth aborts a login that is waiting for the SSO callback.
+func (a *App) CancelAuth() {
+	a.Login.Cancel()
+}
+
+// GetCharactersMissingScopes lists the characters that have to log in again
+// before every feature can be used.
+func (a *App) GetCharactersMissingScopes() []eve.CharacterScopes {
+	return a.Sessions.MissingScopes()
+}
 
-	initialCharacter := ctx.Value("current_character").(eve.AccessTokenJWT)
+// UpgradeScopes starts an SSO login requesting the scopes of every feature
+// together with every scope already granted to a registered character.
+func (a *App) UpgradeScopes() error {
+	scopes := [][]string{eve.RequiredScopes()}
 
-	charAuth := eve.ESIAuth{
-		AccessToken:   ctx.Value("access_token_" + initialCharacter.Name).(string),
-		RefreshToken:  ctx.Value("refresh_token_" + initialCharacter.Name).(string),
-		CharacterName: initialCharacter.Name,
+	for _, character := range a.Sessions.MissingScopes() {
+		scopes = append(scopes, character.Granted)
 	}
 
-	writeCharacters(charAuth)
+	return a.login(eve.UnionScopes(scopes...))
 }
 
+func (a *App) login(scopes []string) error {
+	session, err := a.Login.Login(a.context(), scopes, browser.OpenURL)
+
+	if err != nil {
+		return err
+	}
+
+	a.Sessions.Put(session)
+	a.Sessions.SetCurrent(session.Character.Name)
+
+	a.writeCharacters(session.Auth())
+
+	a.emitAuth()
+	a.Watcher.Wake()
+
+	return nil
+}
+
+// GetZkill returns the most recent kills in a system. Asking for another
+// system cancels a fetch that is still running for the previous one.
 func (a *App) GetZkill(systemId int64) ([]eve.FrontendKillmail, error) {
-	killmails, err := eve.GetSystemKills(int(systemId), 0, a.Cache)
+	a.killsMu.Lock()
+
+	if a.killsCancel != nil && a.killsSystem != systemId {
+		a.killsCancel()
+	}
+
+	ctx, cancel := context.WithCancel(a.context())
+
+	a.killsSystem = systemId
+	a.killsCancel = cancel
+
+	a.killsMu.Unlock()
+
+	defer cancel()
+
+	killmails, err := a.Client.GetSystemKills(ctx, int(systemId), 0, a.Cache)
 
 	if err != nil {
 		return nil, err
@@ -70,297 +228,442 @@ func (a *App) GetZkill(systemId int64) ([]eve.FrontendKillmail, error) {
 	return killmails, nil
 }
 
-func (a *App) GetLocation() (eve.LocationResponse, error) {
-	locationResponse, err := eve.GetLocation(a.ctx)
+// GetErrorBudget reports how many ESI errors are left before requests are
+// paused.
+func (a *App) GetErrorBudget() eve.ErrorBudget {
+	return a.Client.ErrorBudget()
+}
 
-	if err != nil {
-		if err.Error() == "403 forbidden" {
-			if a.ctx.Value("current_character") == nil {
-				return eve.LocationResponse{}, errors.New("there are no characters logged in right now")
-			}
+// GetCacheStats reports hits, misses and evictions of the killmail and name
+// caches.
+func (a *App) GetCacheStats() map[string]eve.CacheStats {
+	return a.Cache.Stats()
+}
 
-			character := a.ctx.Value("current_character").(eve.AccessTokenJWT).Name
+// GetHTTPCacheStats reports how many ESI and zKillboard requests were served
+// from the HTTP cache.
+func (a *App) GetHTTPCacheStats() eve.HTTPCacheStats {
+	return a.Client.HTTPCacheStats()
+}
 
-			ctx, err := eve.RefreshToken(a.ctx, eve.ESIAuth{
-				CharacterName: character,
-				AccessToken:   a.ctx.Value("access_token_" + character).(string),
-				RefreshToken:  a.ctx.Value("refresh_token_" + character).(string),
-			})
+func (a *App) GetLocation() (eve.LocationResponse, error) {
+	locationResponse, err := a.Client.GetLocation(a.Sessions)
 
-			if err != nil {
-				return eve.LocationResponse{}, err
-			}
+	if errors.Is(err, eve.ErrForbidden) {
+		session, err := a.Sessions.Current()
+
+		if err != nil {
+			return eve.LocationResponse{}, err
+		}
+
+		_, err = a.Refresher.Refresh(session.Character.Name)
 
-			a.ctx = ctx
+		if err != nil {
+			return eve.LocationResponse{}, err
 		}
 
+		return a.Client.GetLocation(a.Sessions)
+	}
+
+	if err != nil {
 		return eve.LocationResponse{}, err
 	}
 
 	return locationResponse, nil
 }
 
-func (a *App) SwitchCurrentCharacter(characterName string) error {
-	homeDir, err := os.UserHomeDir()
+// GetAllLocations returns the last location seen of every registered
+// character, which are all tracked at the same time.
+func (a *App) GetAllLocations() []eve.CharacterLocation {
+	return a.Watcher.Locations()
+}
+
+// PinCharacter makes a character the current one so the location and kill
+// feed follow it. Unlike SwitchCurrentCharacter it does not wait for a token
+// refresh; the watcher refreshes the token if ESI rejects it.
+func (a *App) PinCharacter(characterName string) error {
+	err := a.Sessions.SetCurrent(characterName)
 
 	if err != nil {
 		return err
 	}
 
-	chaperonePath := homeDir + "/.eve-chaperone"
+	a.emitAuth()
+	a.Watcher.Wake()
 
-	err = os.MkdirAll(chaperonePath, os.ModePerm)
+	return nil
+}
 
-	if err != nil {
-		return err
+// GetLastJumps returns the last jumps of a character, newest first.
+func (a *App) GetLastJumps(characterName string, count int) []eve.JumpLogEntry {
+	return a.JumpLog.LastJumps(characterName, count)
+}
+
+// GetTimePerSystem adds up the minutes a character spent in each system over
+// the last hours, or over the whole log when hours is 0.
+func (a *App) GetTimePerSystem(characterName string, hours int) []eve.SystemTime {
+	var since time.Time
+
+	if hours > 0 {
+		since = time.Now().Add(-time.Duration(hours) * time.Hour)
 	}
 
-	var esiAuths []eve.ESIAuth
+	return a.JumpLog.TimePerSystem(characterName, since, time.Now())
+}
 
-	currentAuths, err := os.ReadFile(chaperonePath + "./config.json")
+// GetTodaysRoute returns the jumps a character made since local midnight,
+// led by the system it started the day in.
+func (a *App) GetTodaysRoute(characterName string) []eve.JumpLogEntry {
+	now := time.Now()
 
-	if err != nil {
-		return err
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	return a.JumpLog.Route(characterName, midnight)
+}
+
+// ExportJumpLog asks where to save the jump log of a character, or of every
+// character when the name is empty, and writes it as csv or json. It returns
+// the path written, or an empty path when the dialog was cancelled.
+func (a *App) ExportJumpLog(format string, characterName string) (string, error) {
+	write := eve.WriteJumpLogCSV
+
+	switch format {
+	case "csv":
+	case "json":
+		write = eve.WriteJumpLogJSON
+	default:
+		return "", fmt.Errorf("%w: %s", ErrExportFormat, format)
+	}
+
+	name := "jumplog"
+
+	if characterName != "" {
+		name += "-" + characterName
+	}
+
+	path, err := runtime.SaveFileDialog(a.context(), runtime.SaveDialogOptions{
+		DefaultFilename: name + "." + format,
+		Title:           "Export jump log",
+	})
+
+	if err != nil || path == "" {
+		return "", err
 	}
 
-	err = json.Unmarshal(currentAuths, &esiAuths)
+	file, err := os.Create(path)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	var esiAuth eve.ESIAuth
+	defer file.Close()
 
-	for _, auth := range esiAuths {
-		if auth.CharacterName == characterName {
-			esiAuth = auth
-		}
+	err = write(file, a.JumpLog.Entries(characterName, time.Time{}))
+
+	if err != nil {
+		return "", err
 	}
 
-	ctx := a.ctx
+	return path, file.Close()
+}
 
-	fmt.Println(ctx.Value("access_token_" + characterName))
+// PlanRoute plans a stargate route between two systems given by name or ID
+// and returns every system on it with its security.
+func (a *App) PlanRoute(from string, to string, options eve.RouteOptions) ([]eve.RouteHop, error) {
+	return a.Stargates.Route(from, to, options)
+}
 
-	ctx, err = eve.RefreshToken(ctx, esiAuth)
+// AssessRoute plans a route like PlanRoute and scores every system on it by
+// its recent kills.
+func (a *App) AssessRoute(from string, to string, options eve.RouteOptions) (eve.RouteAssessment, error) {
+	hops, err := a.Stargates.Route(from, to, options)
 
 	if err != nil {
-		return err
+		return eve.RouteAssessment{}, err
 	}
 
-	esiAuth.AccessToken = ctx.Value("access_token_" + characterName).(string)
-	esiAuth.RefreshToken = ctx.Value("refresh_token_" + characterName).(string)
+	window := time.Duration(options.KillWindowMinutes) * time.Minute
 
-	fmt.Println("refreshed")
+	return a.Client.AssessRoute(a.context(), hops, window, a.Cache)
+}
 
-	writeCharacters(esiAuth)
+// GetWormholeTypes lists every wormhole type with its mass limits, lifetime
+// and destination class.
+func (a *App) GetWormholeTypes() []universe.WormholeType {
+	return universe.Default().WormholeTypes()
+}
 
-	a.ctx = ctx
+// CheckJump measures the distance between two systems in light years and
+// tells whether the ship can jump it directly.
+func (a *App) CheckJump(from string, to string, options eve.JumpOptions) (eve.JumpCheck, error) {
+	return a.Jumps.Check(from, to, options)
+}
 
-	character, err := eve.GetCharacter(ctx.Value("access_token_" + characterName).(string))
+// PlanJumps plans a capital jump route with its fatigue and activation timers.
+func (a *App) PlanJumps(from string, to string, options eve.JumpOptions) (eve.JumpPlan, error) {
+	return a.Jumps.Plan(from, to, options)
+}
+
+func (a *App) SwitchCurrentCharacter(characterName string) error {
+	_, err := a.Refresher.Refresh(characterName)
 
 	if err != nil {
 		return err
 	}
 
-	contex := eve.SetCurrentCharacter(ctx, character)
+	err = a.Sessions.SetCurrent(characterName)
+
+	if err != nil {
+		return err
+	}
 
-	a.ctx = contex
+	a.emitAuth()
+	a.Watcher.Wake()
 
 	return nil
 }
 
-func (a *App) GetRegisteredCharacters() ([]eve.ESIAuth, error) {
-	homeDir, err := os.UserHomeDir()
+// GetRegisteredCharacters lists the names of the characters in the vault.
+// Their tokens never leave the backend.
+func (a *App) GetRegisteredCharacters() ([]string, error) {
+	a.vaultMu.Lock()
+	esiAuths, err := a.Vault.Load()
+	a.vaultMu.Unlock()
 
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	chaperonePath := homeDir + "/.eve-chaperone"
+	names := make([]string, 0, len(esiAuths))
 
-	err = os.MkdirAll(chaperonePath, os.ModePerm)
-
-	if err != nil {
-		log.Fatal(err)
+	for _, esiAuth := range esiAuths {
+		names = append(names, esiAuth.CharacterName)
 	}
 
-	var esiAuths []eve.ESIAuth
+	return names, nil
+}
 
-	currentAuths, err := os.ReadFile(chaperonePath + "./config.json")
+// RemoveCharacter logs out a single character: its refresh token is revoked
+// at the SSO and it is deleted from the vault. If it was the current
+// character, the next registered one takes its place.
+func (a *App) RemoveCharacter(characterName string) error {
+	session, ok := a.Sessions.Get(characterName)
 
-	if err != nil {
-		log.Fatal(err)
+	if !ok {
+		return eve.ErrUnknownCharacter
 	}
 
-	err = json.Unmarshal(currentAuths, &esiAuths)
+	defer a.Watcher.Wake()
+	defer a.emitAuth()
+
+	current, err := a.Sessions.Current()
+
+	wasCurrent := err == nil && current.Character.Name == characterName
+
+	err = a.Client.RevokeToken(session.RefreshToken)
 
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println(err)
 	}
 
-	for _, auth := range esiAuths {
-		ctx := a.ctx
+	a.Sessions.Remove(characterName)
+	a.Refresher.Forget(characterName)
 
-		ctx = context.WithValue(ctx, "access_token_"+auth.CharacterName, auth.AccessToken)
-		ctx = context.WithValue(ctx, "refresh_token_"+auth.CharacterName, auth.RefreshToken)
+	err = a.deleteCharacter(characterName)
 
-		a.ctx = ctx
+	if err != nil {
+		return err
 	}
 
-	return esiAuths, nil
-}
-
-func (a *App) LogOut() {
-	resetCharacters()
+	if wasCurrent {
+		for _, name := range a.Sessions.Characters() {
+			if a.Refresher.IsRevoked(name) {
+				continue
+			}
 
-	a.ctx = context.WithValue(a.ctx, "current_character", nil)
+			return a.Sessions.SetCurrent(name)
+		}
+	}
 
-	runtime.WindowReload(a.ctx)
+	return nil
 }
 
-func (a *App) OnDomReady(ctx context.Context) {
-	stop := make(chan os.Signal, 1)
+// GetRevokedCharacters lists characters that have to log in again because
+// their refresh token is no longer accepted.
+func (a *App) GetRevokedCharacters() []string {
+	return a.Refresher.Revoked()
+}
 
-	signal.Notify(stop, os.Interrupt)
-	signal.Notify(stop, os.Kill)
+// GetAuthState returns what the auth:changed event carries.
+func (a *App) GetAuthState() eve.AuthEvent {
+	state := eve.AuthEvent{
+		Authenticated: a.Sessions.HasCurrent(),
+		Characters:    a.Sessions.Characters(),
+		Revoked:       a.Refresher.Revoked(),
+	}
 
-	var esiAuths []eve.ESIAuth
+	if current, err := a.Sessions.Current(); err == nil {
+		state.Current = current.Character.Name
+	}
 
-	homeDir, err := os.UserHomeDir()
+	a.vaultMu.Lock()
 
-	if err != nil {
-		log.Fatal(err)
+	if a.vaultErr != nil {
+		state.VaultError = a.vaultErr.Error()
 	}
 
-	chaperonePath := homeDir + "/.eve-chaperone"
+	a.vaultMu.Unlock()
+
+	return state
+}
 
-	err = os.MkdirAll(chaperonePath, os.ModePerm)
+// UnlockVault opens a vault that could not be opened at startup with its
+// passphrase, e.g. the VAULT_PASSPHRASE it was created with. An empty
+// passphrase asks the keyring again.
+func (a *App) UnlockVault(passphrase string) error {
+	open := newVault
 
-	if err != nil {
-		log.Fatal(err)
+	if passphrase != "" {
+		open = func() (eve.Vault, error) { return openVault(passphrase) }
 	}
 
-	currentAuths, err := os.ReadFile(chaperonePath + "./config.json")
+	vault, err := open()
 
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	err = json.Unmarshal(currentAuths, &esiAuths)
+	esiAuths, err := vault.Load()
 
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	for _, auth := range esiAuths {
-		ctx := a.ctx
-
-		fmt.Println(auth.CharacterName)
+	a.vaultMu.Lock()
+	a.Vault = vault
+	a.vaultErr = nil
+	a.vaultMu.Unlock()
 
-		ctx = context.WithValue(ctx, "access_token_"+auth.CharacterName, auth.AccessToken)
-		ctx = context.WithValue(ctx, "refresh_token_"+auth.CharacterName, auth.RefreshToken)
+	a.restoreSessions(esiAuths)
 
-		ctx, err = eve.RefreshToken(ctx, auth)
-
-		if err != nil {
-			log.Fatal(err)
-		}
+	return nil
+}
 
-		auth.AccessToken = ctx.Value("access_token_" + auth.CharacterName).(string)
-		auth.RefreshToken = ctx.Value("refresh_token_" + auth.CharacterName).(string)
+func (a *App) emitAuth() {
+	a.emit(eve.EventAuthChanged, a.GetAuthState())
+}
 
-		writeCharacters(auth)
+func (a *App) LogOut() {
+	a.resetCharacters()
 
-		a.ctx = ctx
-	}
+	a.Sessions.Clear()
 
-	go func() {
-		ticker := time.NewTicker(15 * time.Minute)
+	a.emitAuth()
 
-		for {
-			select {
-			case <-stop:
-				return
-			case <-ticker.C:
-				if !eve.CheckAuth(a.ctx) {
-					continue
-				}
+	runtime.WindowReload(a.context())
+}
 
-				ctx = a.ctx
+func (a *App) OnDomReady(ctx context.Context) {
+	a.vaultMu.Lock()
+	esiAuths, err := a.Vault.Load()
 
-				currentCharacter := ctx.Value("current_character").(eve.AccessTokenJWT)
+	if err != nil && a.vaultErr == nil {
+		a.vaultErr = err
+	}
 
-				ctx, err := eve.RefreshToken(ctx, eve.ESIAuth{
-					CharacterName: currentCharacter.Name,
-				})
+	a.vaultMu.Unlock()
 
-				if err != nil {
-					log.Fatal(err)
-				}
+	if err != nil {
+		fmt.Println(err)
+	}
 
-				a.ctx = ctx
+	a.restoreSessions(esiAuths)
 
-				charAuth := eve.ESIAuth{
-					AccessToken:   ctx.Value("access_token_" + currentCharacter.Name).(string),
-					RefreshToken:  ctx.Value("refresh_token_" + currentCharacter.Name).(string),
-					CharacterName: currentCharacter.Name,
-				}
+	// the DOM is ready again after every reload, the background work only
+	// has to start once
+	a.runOnce.Do(func() {
+		ctx, a.stop = signal.NotifyContext(ctx, os.Interrupt)
 
-				writeCharacters(charAuth)
-			}
-		}
-	}()
+		go a.Refresher.Run(ctx)
+		go a.Watcher.Run(ctx)
+	})
 }
 
-func resetCharacters() {
-	homeDir, err := os.UserHomeDir()
+// restoreSessions puts the characters of the vault that are not registered
+// yet into the session manager and refreshes the tokens that are due. The
+// sessions already there are newer than the vault, e.g. after a reload.
+func (a *App) restoreSessions(esiAuths []eve.ESIAuth) {
+	for _, auth := range esiAuths {
+		if _, ok := a.Sessions.Get(auth.CharacterName); ok {
+			continue
+		}
 
-	if err != nil {
-		log.Fatal(err)
+		a.Sessions.Put(eve.Session{
+			Character: eve.AccessTokenJWT{
+				Name:        auth.CharacterName,
+				CharacterID: auth.CharacterID,
+				Scopes:      auth.Scopes,
+			},
+			AccessToken:  auth.AccessToken,
+			RefreshToken: auth.RefreshToken,
+			ExpiresAt:    auth.ExpiresAt,
+			Scopes:       auth.Scopes,
+		})
 	}
 
-	chaperonePath := homeDir + "/.eve-chaperone"
+	a.Refresher.RefreshDue()
 
-	err = os.MkdirAll(chaperonePath, os.ModePerm)
+	a.emitAuth()
+}
 
-	if err != nil {
-		log.Fatal(err)
-	}
+func (a *App) resetCharacters() {
+	a.vaultMu.Lock()
+	defer a.vaultMu.Unlock()
 
-	err = os.WriteFile(chaperonePath+"./config.json", []byte("[]"), fs.ModePerm)
+	err := a.Vault.Save([]eve.ESIAuth{})
 
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println("clearing the vault:", err)
 	}
 }
 
-func writeCharacters(charAuth eve.ESIAuth) {
-	homeDir, err := os.UserHomeDir()
+func (a *App) deleteCharacter(characterName string) error {
+	a.vaultMu.Lock()
+	defer a.vaultMu.Unlock()
+
+	esiAuths, err := a.Vault.Load()
 
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	chaperonePath := homeDir + "/.eve-chaperone"
+	var remaining []eve.ESIAuth
 
-	err = os.MkdirAll(chaperonePath, os.ModePerm)
-
-	if err != nil {
-		log.Fatal(err)
+	for _, esiAuth := range esiAuths {
+		if esiAuth.CharacterName != characterName {
+			remaining = append(remaining, esiAuth)
+		}
 	}
 
-	var esiAuths []eve.ESIAuth
+	return a.Vault.Save(remaining)
+}
 
-	currentAuths, err := os.ReadFile(chaperonePath + "./config.json")
+// writeCharacters stores a character's credentials in the vault unless it
+// was logged out meanwhile.
+func (a *App) writeCharacters(charAuth eve.ESIAuth) {
+	a.vaultMu.Lock()
+	defer a.vaultMu.Unlock()
 
-	if err != nil {
-		log.Fatal(err)
+	if _, ok := a.Sessions.Get(charAuth.CharacterName); !ok {
+		return
 	}
 
-	err = json.Unmarshal(currentAuths, &esiAuths)
+	esiAuths, err := a.Vault.Load()
 
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println("storing "+charAuth.CharacterName+":", err)
+
+		return
 	}
 
 	charFound := false
@@ -369,9 +672,6 @@ func writeCharacters(charAuth eve.ESIAuth) {
 		if esiAuth.CharacterName == charAuth.CharacterName {
 			charFound = true
 
-			fmt.Println(esiAuth.AccessToken)
-			fmt.Println(charAuth.AccessToken)
-
 			esiAuths[i] = charAuth
 		}
 	}
@@ -380,15 +680,9 @@ func writeCharacters(charAuth eve.ESIAuth) {
 		esiAuths = append(esiAuths, charAuth)
 	}
 
-	bytes, err := json.MarshalIndent(esiAuths, "", "  ")
-
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	err = os.WriteFile(chaperonePath+"./config.json", bytes, fs.ModePerm)
+	err = a.Vault.Save(esiAuths)
 
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println("storing "+charAuth.CharacterName+":", err)
 	}
 }
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"eve-chaperone/eve"
+	"eve-chaperone/eve/data"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// newClient builds the ESI client. ESI_BASE_URL, SSO_BASE_URL and
+// ZKILL_BASE_URL point it at local stand-ins instead of the live services;
+// KILLMAIL_WORKERS bounds concurrent killmail fetches.
+func newClient() *eve.Client {
+	client := eve.NewClient()
+
+	if esiURL := os.Getenv("ESI_BASE_URL"); esiURL != "" {
+		client.ESIURL = esiURL
+	}
+
+	if ssoURL := os.Getenv("SSO_BASE_URL"); ssoURL != "" {
+		client.SSOURL = ssoURL
+	}
+
+	if zkillURL := os.Getenv("ZKILL_BASE_URL"); zkillURL != "" {
+		client.ZKillURL = zkillURL
+	}
+
+	if workers, err := strconv.Atoi(os.Getenv("KILLMAIL_WORKERS")); err == nil && workers > 0 {
+		client.KillmailWorkers = workers
+	}
+
+	return client
+}
+
+// openCache opens the killmail and name cache in the config directory, falling
+// back to an in-memory cache when it cannot be read.
+func openCache() *eve.Cache {
+	chaperonePath, err := configDir()
+
+	if err != nil {
+		fmt.Println(err)
+
+		return eve.NewCache()
+	}
+
+	cache, err := eve.OpenCache(filepath.Join(chaperonePath, "cache.db"))
+
+	if err != nil {
+		fmt.Println(err)
+
+		return eve.NewCache()
+	}
+
+	return cache
+}
+
+// openJumpLog opens the jump log in the config directory, falling back to a
+// log kept in memory when it cannot be read.
+func openJumpLog() *eve.JumpLog {
+	chaperonePath, err := configDir()
+
+	if err != nil {
+		fmt.Println(err)
+
+		return eve.NewJumpLog()
+	}
+
+	jumpLog, err := eve.OpenJumpLog(filepath.Join(chaperonePath, "jumplog.jsonl"), eve.DefaultJumpLogRetention)
+
+	if err != nil {
+		fmt.Println(err)
+
+		return eve.NewJumpLog()
+	}
+
+	return jumpLog
+}
+
+// useStaticData lets tables in the sde folder of the config directory take
+// precedence over the static data built into the binary.
+func useStaticData() {
+	chaperonePath, err := configDir()
+
+	if err != nil {
+		fmt.Println(err)
+
+		return
+	}
+
+	data.Dir = filepath.Join(chaperonePath, "sde")
+}
+
+// callbackPort reads SSO_CALLBACK_PORT. It has to match the callback URL
+// registered for ESI_CLIENT_ID; 0 picks a free port.
+func callbackPort() int {
+	port, err := strconv.Atoi(os.Getenv("SSO_CALLBACK_PORT"))
+
+	if err != nil {
+		return eve.DefaultCallbackPort
+	}
+
+	return port
+}
+
+func configDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	chaperonePath := filepath.Join(homeDir, ".eve-chaperone")
+
+	err = os.MkdirAll(chaperonePath, 0700)
+
+	if err != nil {
+		return "", err
+	}
+
+	return chaperonePath, nil
+}
+
+// The vault key is kept in the keyring under this service and account.
+const (
+	keyringService = "eve-chaperone"
+	keyringAccount = "vault"
+)
+
+// newVault opens the credential vault in the config directory. The key is
+// derived from VAULT_PASSPHRASE, or else from a random passphrase kept in the
+// keyring of the operating system. Where there is no keyring the passphrase is
+// kept in a file readable only by the user.
+func newVault() (eve.Vault, error) {
+	chaperonePath, err := configDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase := os.Getenv("VAULT_PASSPHRASE")
+
+	if passphrase == "" {
+		keyPath := filepath.Join(chaperonePath, "vault.key")
+
+		passphrase, err = loadVaultKey(eve.SystemKeyring(), keyPath)
+
+		if errors.Is(err, eve.ErrKeyringUnavailable) {
+			fmt.Println("keeping the vault key in "+keyPath+":", err)
+
+			passphrase, err = loadFileVaultKey(keyPath)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("the vault key could not be read: %w", err)
+		}
+	}
+
+	return openVault(passphrase)
+}
+
+// openVault opens the vault in the config directory with a passphrase.
+func openVault(passphrase string) (eve.Vault, error) {
+	chaperonePath, err := configDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return eve.NewFileVault(filepath.Join(chaperonePath, "config.json"), passphrase), nil
+}
+
+// lockedVault stands in for a vault that could not be opened, so nothing is
+// written over it until it is unlocked.
+type lockedVault struct {
+	err error
+}
+
+func (v lockedVault) Load() ([]eve.ESIAuth, error) {
+	return nil, v.err
+}
+
+func (v lockedVault) Save(auths []eve.ESIAuth) error {
+	return v.err
+}
+
+// loadVaultKey reads the vault key from the keyring, creating one on first
+// use. A key that older versions kept in legacyPath is moved into the keyring.
+func loadVaultKey(keyring eve.Keyring, legacyPath string) (string, error) {
+	passphrase, err := keyring.Get(keyringService, keyringAccount)
+
+	if err == nil {
+		return passphrase, nil
+	}
+
+	if !errors.Is(err, eve.ErrKeyringNotFound) {
+		return "", err
+	}
+
+	raw, err := os.ReadFile(legacyPath)
+
+	switch {
+	case err == nil:
+		passphrase = strings.TrimSpace(string(raw))
+	case errors.Is(err, fs.ErrNotExist):
+		passphrase, err = newVaultKey()
+
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", err
+	}
+
+	err = keyring.Set(keyringService, keyringAccount, passphrase)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Remove(legacyPath)
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Println(err)
+	}
+
+	return passphrase, nil
+}
+
+// loadFileVaultKey reads the vault key from path, creating one on first use.
+func loadFileVaultKey(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+
+	if err == nil {
+		return strings.TrimSpace(string(raw)), nil
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	passphrase, err := newVaultKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	err = eve.WritePrivateFile(path, []byte(passphrase))
+
+	if err != nil {
+		return "", err
+	}
+
+	return passphrase, nil
+}
+
+func newVaultKey() (string, error) {
+	key := make([]byte, 32)
+
+	_, err := rand.Read(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
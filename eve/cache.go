@@ -1,19 +1,268 @@
 package eve
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	killmailsBucket    = "killmails"
+	charactersBucket   = "characters"
+	corporationsBucket = "corporations"
+	alliancesBucket    = "alliances"
+	shipsBucket        = "ships"
+
+	// names change after corporation and alliance hops, killmails never do
+	nameTTL = 24 * time.Hour
+
+	nameBudget     = 4 << 20
+	shipBudget     = 1 << 20
+	killmailBudget = 32 << 20
+)
+
+// Cache holds killmails, resolved names and the ship types ESI had to be
+// asked about. It is safe for concurrent use;
+// entries are evicted least recently used first once a cache outgrows its
+// memory budget.
 type Cache struct {
-	Characters   map[int64]string
-	Corporations map[int64]string
-	Alliances    map[int64]string
-	Ships        map[int64]string
-	Killmails    map[int64]FrontendKillmail
+	Characters   *TTLCache[int64, string]
+	Corporations *TTLCache[int64, string]
+	Alliances    *TTLCache[int64, string]
+	Ships        *TTLCache[int64, TypeInfo]
+	Killmails    *TTLCache[int64, FrontendKillmail]
+
+	store *Store
+}
+
+type cachedName struct {
+	Name    string    `json:"name"`
+	Expires time.Time `json:"expires"`
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		Characters:   NewTTLCache[int64, string](nameTTL, nameBudget, nameSize),
+		Corporations: NewTTLCache[int64, string](nameTTL, nameBudget, nameSize),
+		Alliances:    NewTTLCache[int64, string](nameTTL, nameBudget, nameSize),
+		Ships:        NewTTLCache[int64, TypeInfo](0, shipBudget, typeSize),
+		Killmails:    NewTTLCache[int64, FrontendKillmail](0, killmailBudget, killmailSize),
+	}
+}
+
+// OpenCache loads a cache persisted at path and writes every new entry
+// through to it.
+func OpenCache(path string) (*Cache, error) {
+	store, err := OpenStore(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cache := NewCache()
+	cache.store = store
+
+	buckets := map[string]*TTLCache[int64, string]{
+		charactersBucket:   cache.Characters,
+		corporationsBucket: cache.Corporations,
+		alliancesBucket:    cache.Alliances,
+	}
+
+	now := time.Now()
+
+	for bucket, names := range buckets {
+		err = store.Each(bucket, func(key string, value json.RawMessage) error {
+			id, err := strconv.ParseInt(key, 10, 64)
+
+			if err != nil {
+				return nil
+			}
+
+			name := cachedName{}
+
+			if json.Unmarshal(value, &name) != nil {
+				return nil
+			}
+
+			if !name.Expires.IsZero() && now.After(name.Expires) {
+				return nil
+			}
+
+			names.SetUntil(id, name.Name, name.Expires)
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = store.Each(shipsBucket, func(key string, value json.RawMessage) error {
+		info := TypeInfo{}
+
+		if json.Unmarshal(value, &info) == nil {
+			cache.Ships.Set(info.Id, info)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Each(killmailsBucket, func(key string, value json.RawMessage) error {
+		killmail := FrontendKillmail{}
+
+		if json.Unmarshal(value, &killmail) == nil {
+			cache.Killmails.Set(killmail.KillmailId, killmail)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+func (c *Cache) Killmail(id int64) (FrontendKillmail, bool) {
+	killmail, ok := c.Killmails.Get(id)
+
+	if ok {
+		c.touch(killmailsBucket, id)
+	}
+
+	return killmail, ok
+}
+
+func (c *Cache) SetKillmail(killmail FrontendKillmail) {
+	c.Killmails.Set(killmail.KillmailId, killmail)
+
+	c.persist(killmailsBucket, killmail.KillmailId, killmail)
+}
+
+// Name looks up a name in the cache of its category: "character",
+// "corporation" or "alliance".
+func (c *Cache) Name(category string, id int64) (string, bool) {
+	switch category {
+	case "character":
+		return c.name(c.Characters, charactersBucket, id)
+	case "corporation":
+		return c.name(c.Corporations, corporationsBucket, id)
+	case "alliance":
+		return c.name(c.Alliances, alliancesBucket, id)
+	}
+
+	return "", false
+}
+
+// Ship returns a ship type that was fetched from ESI before.
+func (c *Cache) Ship(id int64) (TypeInfo, bool) {
+	info, ok := c.Ships.Get(id)
+
+	if ok {
+		c.touch(shipsBucket, id)
+	}
+
+	return info, ok
+}
+
+func (c *Cache) SetShip(info TypeInfo) {
+	c.Ships.Set(info.Id, info)
+
+	c.persist(shipsBucket, info.Id, info)
+}
+
+func (c *Cache) SetName(name UniverseName) {
+	switch name.Category {
+	case "character":
+		c.setName(c.Characters, charactersBucket, name.Id, name.Name)
+	case "corporation":
+		c.setName(c.Corporations, corporationsBucket, name.Id, name.Name)
+	case "alliance":
+		c.setName(c.Alliances, alliancesBucket, name.Id, name.Name)
+	}
+}
+
+func (c *Cache) Stats() map[string]CacheStats {
+	return map[string]CacheStats{
+		charactersBucket:   c.Characters.Stats(),
+		corporationsBucket: c.Corporations.Stats(),
+		alliancesBucket:    c.Alliances.Stats(),
+		shipsBucket:        c.Ships.Stats(),
+		killmailsBucket:    c.Killmails.Stats(),
+	}
+}
+
+func (c *Cache) Close() error {
+	if c.store == nil {
+		return nil
+	}
+
+	return c.store.Close()
 }
 
-func NewCache() Cache {
-	return Cache{
-		Characters:   make(map[int64]string),
-		Corporations: make(map[int64]string),
-		Alliances:    make(map[int64]string),
-		Ships:        make(map[int64]string),
-		Killmails:    make(map[int64]FrontendKillmail),
+func (c *Cache) setName(names *TTLCache[int64, string], bucket string, id int64, name string) {
+	names.Set(id, name)
+
+	var expires time.Time
+
+	if names.TTL() > 0 {
+		expires = time.Now().Add(names.TTL())
 	}
+
+	c.persist(bucket, id, cachedName{Name: name, Expires: expires})
+}
+
+func (c *Cache) name(names *TTLCache[int64, string], bucket string, id int64) (string, bool) {
+	name, ok := names.Get(id)
+
+	if ok {
+		c.touch(bucket, id)
+	}
+
+	return name, ok
+}
+
+func (c *Cache) persist(bucket string, id int64, value interface{}) {
+	if c.store == nil {
+		return
+	}
+
+	err := c.store.Put(bucket, strconv.FormatInt(id, 10), value)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// touch keeps the store's access order in step with the memory caches, so a
+// record that is read often is not the first to be evicted from disk.
+func (c *Cache) touch(bucket string, id int64) {
+	if c.store != nil {
+		c.store.Touch(bucket, strconv.FormatInt(id, 10))
+	}
+}
+
+func nameSize(name string) int64 {
+	return int64(len(name)) + 64
+}
+
+func typeSize(info TypeInfo) int64 {
+	return int64(len(info.Name)+len(info.Group)+len(info.Category)) + 128
+}
+
+func killmailSize(killmail FrontendKillmail) int64 {
+	size := int64(len(killmail.KillmailTime)) + 256
+
+	for _, attacker := range killmail.Attackers {
+		size += int64(len(attacker.Character)+len(attacker.Corporation)+len(attacker.Alliance)+len(attacker.ShipType)) + 128
+	}
+
+	return size
 }
@@ -0,0 +1,33 @@
+package eve
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCachePersistsShips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache, err := OpenCache(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.SetShip(TypeInfo{Id: 89807, Name: "Zarmazd", GroupId: 1972})
+	cache.Close()
+
+	cache, err = OpenCache(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cache.Close()
+
+	info, ok := cache.Ship(89807)
+
+	if !ok || info.Name != "Zarmazd" {
+		t.Errorf("Ship() after reopening = %+v, %v", info, ok)
+	}
+}
@@ -0,0 +1,208 @@
+package eve
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultESIURL     = "https://esi.evetech.net/latest"
+	DefaultSSOURL     = "https://login.eveonline.com"
+	DefaultZKillURL   = "https://zkillboard.com/api"
+	DefaultUserAgent  = "eve-chaperone (+https://github.com/EVE-Toolkit/eve-chaperone)"
+	DefaultDatasource = "tranquility"
+
+	DefaultKillmailWorkers = 4
+	DefaultRouteWorkers    = 4
+)
+
+// Client talks to ESI, the EVE SSO and zKillboard. Every base URL can be
+// pointed at a local stand-in.
+type Client struct {
+	ESIURL     string
+	SSOURL     string
+	ZKillURL   string
+	ClientID   string
+	UserAgent  string
+	Datasource string
+	HTTPClient *http.Client
+
+	// KillmailWorkers bounds how many killmails are fetched at once.
+	KillmailWorkers int
+	// RouteWorkers bounds how many systems of a route are assessed at once.
+	RouteWorkers int
+	// Cache keeps the ship types that had to be fetched from ESI.
+	Cache *Cache
+
+	keys  *jwksCache
+	cache *CachingTransport
+
+	// systems describes the systems missing from the static map
+	systemsMu sync.Mutex
+	systems   map[int64]LocationResponse
+
+	limitersMu sync.Mutex
+	limiters   map[string]*ErrorLimiter
+}
+
+func NewClient() *Client {
+	cache := NewCachingTransport(http.DefaultTransport)
+
+	return &Client{
+		ESIURL:     DefaultESIURL,
+		SSOURL:     DefaultSSOURL,
+		ZKillURL:   DefaultZKillURL,
+		ClientID:   os.Getenv("ESI_CLIENT_ID"),
+		UserAgent:  DefaultUserAgent,
+		Datasource: DefaultDatasource,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second, Transport: cache},
+
+		KillmailWorkers: DefaultKillmailWorkers,
+		RouteWorkers:    DefaultRouteWorkers,
+		Cache:           NewCache(),
+
+		keys:     &jwksCache{},
+		cache:    cache,
+		systems:  make(map[int64]LocationResponse),
+		limiters: make(map[string]*ErrorLimiter),
+	}
+}
+
+// esiRoute builds an ESI URL for path, adding the datasource to query.
+func (c *Client) esiRoute(path string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	query.Set("datasource", c.Datasource)
+
+	return strings.TrimSuffix(c.ESIURL, "/") + path + "?" + query.Encode()
+}
+
+func (c *Client) ssoRoute(path string) string {
+	return strings.TrimSuffix(c.SSOURL, "/") + path
+}
+
+func (c *Client) zkillRoute(path string) string {
+	return strings.TrimSuffix(c.ZKillURL, "/") + path
+}
+
+// ssoIssuers lists the iss claims accepted for tokens from c.SSOURL.
+func (c *Client) ssoIssuers() []string {
+	u, err := url.Parse(c.SSOURL)
+
+	if err != nil {
+		return []string{c.SSOURL}
+	}
+
+	return []string{u.Host, u.Scheme + "://" + u.Host}
+}
+
+// ErrorBudget reports the ESI error budget left before requests are paused.
+func (c *Client) ErrorBudget() ErrorBudget {
+	return c.limiter(c.ESIURL).Budget()
+}
+
+func (c *Client) HTTPCacheStats() HTTPCacheStats {
+	return c.cache.Stats()
+}
+
+func (c *Client) limiter(rawURL string) *ErrorLimiter {
+	host := rawURL
+
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	c.limitersMu.Lock()
+	defer c.limitersMu.Unlock()
+
+	limiter, ok := c.limiters[host]
+
+	if !ok {
+		limiter = NewErrorLimiter()
+
+		c.limiters[host] = limiter
+	}
+
+	return limiter
+}
+
+func (c *Client) get(url string, priority Priority) (*http.Response, error) {
+	return c.getContext(context.Background(), url, priority)
+}
+
+func (c *Client) getContext(ctx context.Context, url string, priority Priority) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(req, priority)
+}
+
+// getAuthorized requests url with the session's access token. The response
+// is cached for the session's character only.
+func (c *Client) getAuthorized(url string, session Session, priority Priority) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		withCacheCharacter(context.Background(), session.Character.CharacterID),
+		"GET",
+		url,
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+session.AccessToken)
+
+	return c.do(req, priority)
+}
+
+func (c *Client) postForm(url string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequest("POST", url, strings.NewReader(data.Encode()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.do(req, PriorityHigh)
+}
+
+func (c *Client) do(req *http.Request, priority Priority) (*http.Response, error) {
+	limiter := c.limiter(req.URL.String())
+
+	err := limiter.Acquire(req.Context(), priority)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	res, err := c.HTTPClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	limiter.Observe(res)
+
+	if res.StatusCode == 420 || res.StatusCode == http.StatusTooManyRequests {
+		res.Body.Close()
+
+		return nil, fmt.Errorf("%w: %s", ErrRateLimited, res.Status)
+	}
+
+	return res, nil
+}
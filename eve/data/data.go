@@ -0,0 +1,169 @@
+// Package data bundles the static data eve-chaperone needs from the EVE
+// static data export (SDE). The tables are generated by `eve-chaperone sde
+// import`, one JSON array per table sorted by ID.
+package data
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+const (
+	TypesFile          = "types.json"
+	GroupsFile         = "groups.json"
+	CategoriesFile     = "categories.json"
+	RegionsFile        = "regions.json"
+	ConstellationsFile = "constellations.json"
+	SystemsFile        = "systems.json"
+	StargatesFile      = "stargates.json"
+	WormholesFile      = "wormholes.json"
+
+	// WormholeStaticsFile is not part of the SDE, so it is not written by
+	// the import and has to be kept up to date by hand from the statics
+	// Anoik.is lists for every J-space system.
+	WormholeStaticsFile = "wormhole_statics.json"
+
+	// ChecksumFile lists the SHA-256 of every table in the format of
+	// sha256sum, so a regeneration can be checked with sha256sum -c.
+	ChecksumFile = "sha256sums.txt"
+)
+
+// Files are the tables in the order they are written.
+var Files = []string{
+	TypesFile,
+	GroupsFile,
+	CategoriesFile,
+	RegionsFile,
+	ConstellationsFile,
+	SystemsFile,
+	StargatesFile,
+	WormholesFile,
+}
+
+//go:embed *.json
+var bundle embed.FS
+
+var ErrEmptyTable = errors.New("the table is empty; generate it with eve-chaperone sde import")
+
+// Dir, when set, is searched before the embedded bundle so a newer export can
+// be used without rebuilding. It has to be set before the first lookup.
+var Dir string
+
+type Type struct {
+	Id        int64   `json:"id"`
+	Name      string  `json:"name"`
+	GroupId   int64   `json:"group_id"`
+	Mass      float64 `json:"mass"`
+	MetaLevel int     `json:"meta_level"`
+}
+
+type Group struct {
+	Id         int64  `json:"id"`
+	Name       string `json:"name"`
+	CategoryId int64  `json:"category_id"`
+}
+
+type Category struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Region struct {
+	Id              int64  `json:"id"`
+	Name            string `json:"name"`
+	WormholeClassId int    `json:"wormhole_class_id,omitempty"`
+}
+
+type Constellation struct {
+	Id              int64  `json:"id"`
+	Name            string `json:"name"`
+	RegionId        int64  `json:"region_id"`
+	WormholeClassId int    `json:"wormhole_class_id,omitempty"`
+}
+
+// System is a solar system. Its position is in metres; the wormhole class is
+// inherited from the constellation or region when the system has none. Effect
+// names the phenomenon of wormhole systems that have one, e.g. Pulsar.
+type System struct {
+	Id              int64   `json:"id"`
+	Name            string  `json:"name"`
+	ConstellationId int64   `json:"constellation_id"`
+	RegionId        int64   `json:"region_id"`
+	Security        float64 `json:"security"`
+	X               float64 `json:"x"`
+	Y               float64 `json:"y"`
+	Z               float64 `json:"z"`
+	WormholeClassId int     `json:"wormhole_class_id,omitempty"`
+	Effect          string  `json:"effect,omitempty"`
+}
+
+type Stargate struct {
+	Id                  int64 `json:"id"`
+	SystemId            int64 `json:"system_id"`
+	DestinationId       int64 `json:"destination_id"`
+	DestinationSystemId int64 `json:"destination_system_id"`
+}
+
+// Wormhole is a wormhole type, named by its code such as B274. Masses are in
+// kilograms and the lifetime in minutes.
+type Wormhole struct {
+	Id               int64   `json:"id"`
+	Name             string  `json:"name"`
+	TargetClassId    int     `json:"target_class_id"`
+	MaxStableTime    float64 `json:"max_stable_time"`
+	MaxStableMass    float64 `json:"max_stable_mass"`
+	MaxJumpMass      float64 `json:"max_jump_mass"`
+	MassRegeneration float64 `json:"mass_regeneration"`
+}
+
+// WormholeStatic lists the wormhole types that always lead out of a system.
+type WormholeStatic struct {
+	SystemId  int64    `json:"system_id"`
+	Wormholes []string `json:"wormholes"`
+}
+
+// ReadFile returns the named table from Dir if it is there, or else from the
+// embedded bundle.
+func ReadFile(name string) ([]byte, error) {
+	if Dir != "" {
+		b, err := os.ReadFile(filepath.Join(Dir, name))
+
+		if err == nil {
+			return b, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return bundle.ReadFile(name)
+}
+
+// Load decodes the named table into v, which should point to a slice of the
+// table's record type. A table without records fails with ErrEmptyTable.
+func Load(name string, v interface{}) error {
+	b, err := ReadFile(name)
+
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(b, v)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if reflect.Indirect(reflect.ValueOf(v)).Len() == 0 {
+		return fmt.Errorf("%s: %w", name, ErrEmptyTable)
+	}
+
+	return nil
+}
@@ -1,41 +1,44 @@
 package eve
 
 import (
-	"bytes"
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"math/big"
-	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/dvsekhvalnov/jose2go/base64url"
+	"eve-chaperone/eve/universe"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
-const BaseESIRoute = "https://esi.evetech.net/latest"
-
 type ESIAuthResponse struct {
 	AccessToken  string `json:"access_token"`
 	ExpiresIn    int    `json:"expires_in"`
 	TokenType    string `json:"token_type"`
 	RefreshToken string `json:"refresh_token"`
+
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
 }
 
+var ErrForbidden = errors.New("403 forbidden")
+
+var ErrRefreshTokenRevoked = errors.New("the refresh token has been revoked")
+
+// ESIAuth is what the vault keeps of a session. Records written by older
+// versions have no character ID or expiry.
 type ESIAuth struct {
-	AccessToken   string `json:"access_token"`
-	RefreshToken  string `json:"refresh_token"`
-	CharacterName string `json:"name"`
+	AccessToken   string    `json:"access_token"`
+	RefreshToken  string    `json:"refresh_token"`
+	CharacterName string    `json:"name"`
+	CharacterID   int64     `json:"character_id"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Scopes        []string  `json:"scopes"`
 }
 
 type SolarSystemResponse struct {
@@ -44,421 +47,409 @@ type SolarSystemResponse struct {
 	StructureId   int `json:"structure_id"`
 }
 
+// LocationResponse is where a character is. Wormhole is only set in J-space,
+// Thera and Pochven.
 type LocationResponse struct {
-	Name          string `json:"name"`
-	SolarSystemId int    `json:"solar_system_id"`
-	StationId     int    `json:"station_id"`
-	StructureId   int    `json:"structure_id"`
+	Name            string             `json:"name"`
+	SolarSystemId   int                `json:"solar_system_id"`
+	StationId       int                `json:"station_id"`
+	StructureId     int                `json:"structure_id"`
+	SecurityStatus  float64            `json:"security_status"`
+	RoundedSecurity float64            `json:"rounded_security"`
+	ConstellationId int64              `json:"constellation_id"`
+	Constellation   string             `json:"constellation"`
+	RegionId        int64              `json:"region_id"`
+	Region          string             `json:"region"`
+	SpaceType       universe.SpaceType `json:"space_type"`
+	ShipItemId      int64              `json:"ship_item_id"`
+	ShipTypeId      int64              `json:"ship_type_id"`
+	ShipType        string             `json:"ship_type"`
+	ShipName        string             `json:"ship_name"`
+	Wormhole        *universe.Wormhole `json:"wormhole,omitempty"`
 }
 
-type JWKS struct {
-	Keys []jsonWebKey `json:"keys"`
-}
-
-type jsonWebKey struct {
-	Alg string `json:"alg"`
-	Kid string `json:"kid"`
-	Kty string `json:"kty"`
-	Use string `json:"use"`
-	N   string `json:"n"`
-	E   string `json:"e"`
-	X   string `json:"x,omitempty"`
-	Y   string `json:"y,omitempty"`
+type ShipResponse struct {
+	ShipItemId int64  `json:"ship_item_id"`
+	ShipTypeId int64  `json:"ship_type_id"`
+	ShipName   string `json:"ship_name"`
 }
 
 type AccessTokenJWT struct {
-	Name string `json:"name"`
-	Sub  string `json:"sub"`
-	Iss  string `json:"iss"`
+	Name        string    `json:"name"`
+	Sub         string    `json:"sub"`
+	Iss         string    `json:"iss"`
+	CharacterID int64     `json:"character_id"`
+	Owner       string    `json:"owner"`
+	Scopes      []string  `json:"scopes"`
+	ExpiresAt   time.Time `json:"expires_at"`
 }
 
-func CheckAuth(ctx context.Context) bool {
-	return ctx.Value("current_character") != nil
-}
+// GetLocation returns the location of the current character.
+func (c *Client) GetLocation(sessions *SessionManager) (LocationResponse, error) {
+	session, err := sessions.Current()
 
-func GetCurrentCharacter(ctx context.Context) AccessTokenJWT {
-	return ctx.Value("current_character").(AccessTokenJWT)
-}
+	if err != nil {
+		return LocationResponse{}, err
+	}
 
-func SetCurrentCharacter(ctx context.Context, character AccessTokenJWT) context.Context {
-	return context.WithValue(ctx, "current_character", character)
+	return c.GetCharacterLocation(session)
 }
 
-func GetAccessToken(ctx context.Context) (context.Context, error) {
-
-	done := make(chan bool)
-
-	var state string
-	var challengeBytes string
-	var codeChallenge string
-
-	esiAuthResponse := ESIAuthResponse{}
-
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
-		cb, err := generateState()
-
-		if err != nil {
-			fmt.Println(err)
-
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-
-			return
-		}
-
-		st, err := generateState()
-
-		if err != nil {
-			fmt.Println(err)
-
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-
-			return
-		}
-
-		hash := sha256.New()
+func (c *Client) GetCharacterLocation(session Session) (LocationResponse, error) {
+	characterId := strconv.FormatInt(session.Character.CharacterID, 10)
 
-		hash.Write([]byte(cb))
+	res, err := c.getAuthorized(c.esiRoute("/characters/"+characterId+"/location/", nil), session, PriorityHigh)
 
-		cc := base64url.Encode(hash.Sum(nil))
-
-		state = st
-		challengeBytes = cb
-		codeChallenge = cc
-
-		data := url.Values{}
-
-		data.Add("Response_type", "code")
-		data.Add("redirect_uri", "http://localhost:3003/callback")
-		data.Add("client_id", os.Getenv("ESI_CLIENT_ID"))
-		data.Add("scope", "esi-location.read_location.v1")
-		data.Add("code_challenge", codeChallenge)
-		data.Add("code_challenge_method", "S256")
-		data.Add("state", state)
-
-		http.Redirect(w, r, "https://login.eveonline.com/v2/oauth/authorize?"+data.Encode(), http.StatusTemporaryRedirect) // redirect user to oauth endpoint
-	})
-
-	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		st := r.URL.Query().Get("state")
+	if err != nil {
+		return LocationResponse{}, err
+	}
 
-		if st != state {
-			http.Error(w, "the state is not valid.", http.StatusInternalServerError)
+	defer res.Body.Close()
 
-			return
+	if res.StatusCode != http.StatusOK {
+		if res.StatusCode == http.StatusForbidden {
+			return LocationResponse{}, ErrForbidden
 		}
 
-		tokenData := url.Values{}
+		return LocationResponse{}, errors.New(res.Status)
+	}
 
-		tokenData.Add("grant_type", "authorization_code")
-		tokenData.Add("code", code)
-		tokenData.Add("client_id", os.Getenv("ESI_CLIENT_ID"))
-		tokenData.Add("code_verifier", challengeBytes)
+	solarSystemResponse := SolarSystemResponse{}
 
-		req, err := http.NewRequest(
-			"POST",
-			"https://login.eveonline.com/v2/oauth/token",
-			bytes.NewReader([]byte(tokenData.Encode())),
-		)
+	err = ProcessBody(res.Body, &solarSystemResponse)
 
-		if err != nil {
-			fmt.Println(err)
+	if err != nil {
+		return LocationResponse{}, err
+	}
 
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	locationResponse, err := c.describeSystem(int64(solarSystemResponse.SolarSystemId))
 
-			return
-		}
+	if err != nil {
+		return LocationResponse{}, err
+	}
 
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		req.Header.Set("Host", "login.eveonline.com")
+	locationResponse.SolarSystemId = solarSystemResponse.SolarSystemId
+	locationResponse.StationId = solarSystemResponse.StationId
+	locationResponse.StructureId = solarSystemResponse.StructureId
 
-		res, err := http.DefaultClient.Do(req)
+	// characters that logged in before the ship scope was added have no ship
+	if contains(session.Scopes, ScopeReadShipType) {
+		ship, err := c.getShip(session)
 
 		if err != nil {
-			fmt.Println(err)
-
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-
-			return
+			return LocationResponse{}, err
 		}
 
-		defer res.Body.Close()
-
-		fmt.Println(res.Status)
-		fmt.Println(res.StatusCode)
-
-		err = ProcessBody(res.Body, &esiAuthResponse)
+		locationResponse.ShipItemId = ship.ShipItemId
+		locationResponse.ShipTypeId = ship.ShipTypeId
+		locationResponse.ShipName = ship.ShipName
+		locationResponse.ShipType, _ = c.GetShipName(context.Background(), ship.ShipTypeId)
+	}
 
-		if err != nil {
-			fmt.Println(err)
+	return locationResponse, nil
+}
 
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+func (c *Client) getShip(session Session) (ShipResponse, error) {
+	characterId := strconv.FormatInt(session.Character.CharacterID, 10)
 
-			return
-		}
+	res, err := c.getAuthorized(c.esiRoute("/characters/"+characterId+"/ship/", nil), session, PriorityHigh)
 
-		if esiAuthResponse.AccessToken == "" {
-			fmt.Println(err)
+	if err != nil {
+		return ShipResponse{}, err
+	}
 
-			http.Error(w, errors.New("there was an error authenticating").Error(), http.StatusInternalServerError)
+	defer res.Body.Close()
 
-			return
+	if res.StatusCode != http.StatusOK {
+		if res.StatusCode == http.StatusForbidden {
+			return ShipResponse{}, ErrForbidden
 		}
 
-		character, err := GetCharacter(esiAuthResponse.AccessToken)
+		return ShipResponse{}, errors.New(res.Status)
+	}
 
-		if err != nil {
-			fmt.Println(err)
+	ship := ShipResponse{}
 
-			http.Error(w, errors.New("there was an error authenticating").Error(), http.StatusInternalServerError)
+	err = ProcessBody(res.Body, &ship)
 
-			return
-		}
+	if err != nil {
+		return ShipResponse{}, err
+	}
 
-		ctx = context.WithValue(ctx, "access_token_"+character.Name, esiAuthResponse.AccessToken)
-		ctx = context.WithValue(ctx, "refresh_token_"+character.Name, esiAuthResponse.RefreshToken)
-		ctx = SetCurrentCharacter(ctx, character)
+	return ship, nil
+}
 
-		done <- true
+// LocationExpires returns when ESI will next have a new location for the
+// session's character, or the zero time when it has not been fetched yet.
+func (c *Client) LocationExpires(session Session) time.Time {
+	characterId := strconv.FormatInt(session.Character.CharacterID, 10)
 
-		w.WriteHeader(200)
-		w.Write([]byte("You were authenticated."))
-	})
+	req, err := http.NewRequestWithContext(
+		withCacheCharacter(context.Background(), session.Character.CharacterID),
+		"GET",
+		c.esiRoute("/characters/"+characterId+"/location/", nil),
+		nil,
+	)
 
-	server := &http.Server{Addr: ":3003", Handler: mux}
+	if err != nil {
+		return time.Time{}
+	}
 
-	go func() {
-		_, err := net.DialTimeout("tcp", "localhost:3003", 1*time.Second)
+	expires, _ := c.cache.Expires(req)
 
-		if err != nil {
-			err := server.ListenAndServe()
+	return expires
+}
 
-			if err != nil {
-				fmt.Println(err)
-			}
+// describeSystem fills a LocationResponse from the static map, asking ESI only
+// for systems missing from it. Complete answers from ESI are kept for the life
+// of the client, so polling a character in such a system stays local.
+func (c *Client) describeSystem(systemId int64) (LocationResponse, error) {
+	if system, ok := universe.Default().System(systemId); ok {
+		locationResponse := LocationResponse{
+			Name:            system.Name,
+			SecurityStatus:  system.Security,
+			RoundedSecurity: system.RoundedSecurity,
+			ConstellationId: system.ConstellationId,
+			Constellation:   system.Constellation,
+			RegionId:        system.RegionId,
+			Region:          system.Region,
+			SpaceType:       system.SpaceType,
 		}
-	}()
 
-	<-done
-
-	err := server.Close()
+		if wormhole, ok := universe.Default().Wormhole(systemId); ok {
+			locationResponse.Wormhole = &wormhole
+		}
 
-	if err != nil {
-		return ctx, err
+		return locationResponse, nil
 	}
 
-	return ctx, nil
-}
+	c.systemsMu.Lock()
+	locationResponse, ok := c.systems[systemId]
+	c.systemsMu.Unlock()
 
-func GetLocation(ctx context.Context) (LocationResponse, error) {
-	if ctx.Value("current_character") == nil {
-		return LocationResponse{}, errors.New("no character is logged in right now")
+	if ok {
+		return locationResponse, nil
 	}
 
-	character := ctx.Value("current_character").(AccessTokenJWT)
-
-	characterId := character.Sub[strings.LastIndex(character.Sub, ":")+1:]
-
-	data := url.Values{}
-
-	data.Add("datasource", "tranquility")
-	data.Add("token", ctx.Value("access_token_"+character.Name).(string))
-
-	res, err := http.Get(BaseESIRoute + "/characters/" + characterId + "/location?" + data.Encode())
+	location, err := c.get(c.esiRoute("/universe/systems/"+strconv.FormatInt(systemId, 10)+"/", nil), PriorityHigh)
 
 	if err != nil {
 		return LocationResponse{}, err
 	}
 
-	if res.StatusCode != 200 {
-		bytes, _ := io.ReadAll(res.Body)
+	defer location.Body.Close()
 
-		fmt.Println(string(bytes))
-
-		return LocationResponse{}, errors.New(res.Status)
+	if location.StatusCode != http.StatusOK {
+		return LocationResponse{}, errors.New("the system could not be fetched: " + location.Status)
 	}
 
-	solarSystemResponse := SolarSystemResponse{}
+	locationResponse = LocationResponse{}
 
-	err = ProcessBody(res.Body, &solarSystemResponse)
+	err = ProcessBody(location.Body, &locationResponse)
 
 	if err != nil {
 		return LocationResponse{}, err
 	}
 
-	location, err := http.Get(BaseESIRoute + "/universe/systems/" + strconv.Itoa(solarSystemResponse.SolarSystemId))
+	// the names are nice to have, so failing to get them is not fatal, but
+	// the system is asked for again next time
+	complete := false
+
+	constellation, err := c.getUniverseResource("/universe/constellations/", locationResponse.ConstellationId)
 
 	if err != nil {
-		return LocationResponse{}, err
-	}
+		fmt.Println("could not fetch constellation", locationResponse.ConstellationId, err)
+	} else {
+		locationResponse.Constellation = constellation.Name
+		locationResponse.RegionId = constellation.RegionId
 
-	locationResponse := LocationResponse{}
+		region, err := c.getUniverseResource("/universe/regions/", constellation.RegionId)
 
-	err = ProcessBody(location.Body, &locationResponse)
+		if err != nil {
+			fmt.Println("could not fetch region", constellation.RegionId, err)
+		} else {
+			locationResponse.Region = region.Name
 
-	if err != nil {
-		return LocationResponse{}, err
+			complete = true
+		}
 	}
 
-	locationResponse.SolarSystemId = solarSystemResponse.SolarSystemId
-	locationResponse.StationId = solarSystemResponse.StationId
-	locationResponse.StructureId = solarSystemResponse.StructureId
+	locationResponse.RoundedSecurity = universe.RoundSecurity(locationResponse.SecurityStatus)
+	locationResponse.SpaceType = universe.SpaceTypeOf(locationResponse.RegionId, locationResponse.SecurityStatus)
+
+	if complete {
+		c.systemsMu.Lock()
+		c.systems[systemId] = locationResponse
+		c.systemsMu.Unlock()
+	}
 
 	return locationResponse, nil
 }
 
-func GetCharacter(jwtString string) (AccessTokenJWT, error) {
-	token, _, err := jwt.NewParser().ParseUnverified(jwtString, jwt.MapClaims{})
-
-	if err != nil {
-		return AccessTokenJWT{}, err
-	}
+type universeResource struct {
+	Name     string `json:"name"`
+	RegionId int64  `json:"region_id"`
+}
 
-	jwks, err := fetchJWKS()
+func (c *Client) getUniverseResource(path string, id int64) (universeResource, error) {
+	res, err := c.get(c.esiRoute(path+strconv.FormatInt(id, 10)+"/", nil), PriorityLow)
 
 	if err != nil {
-		return AccessTokenJWT{}, err
+		return universeResource{}, err
 	}
 
-	key, err := extractAndValidateKey(token, jwks)
+	defer res.Body.Close()
 
-	if err != nil {
-		return AccessTokenJWT{}, err
+	if res.StatusCode != http.StatusOK {
+		return universeResource{}, errors.New(res.Status)
 	}
 
-	claims := jwt.MapClaims{}
+	resource := universeResource{}
 
-	_, err = jwt.ParseWithClaims(jwtString, claims, func(t *jwt.Token) (interface{}, error) {
-		return key, nil
-	})
+	err = ProcessBody(res.Body, &resource)
 
 	if err != nil {
-		return AccessTokenJWT{}, err
-	}
-
-	character := AccessTokenJWT{
-		Sub:  token.Claims.(jwt.MapClaims)["sub"].(string),
-		Name: token.Claims.(jwt.MapClaims)["name"].(string),
-		Iss:  token.Claims.(jwt.MapClaims)["iss"].(string),
+		return universeResource{}, err
 	}
 
-	return character, nil
+	return resource, nil
 }
 
-func RefreshToken(ctx context.Context, character ESIAuth) (context.Context, error) {
+func (c *Client) RefreshToken(sessions *SessionManager, characterName string) (Session, error) {
 	data := url.Values{}
 
-	refreshToken := ctx.Value("refresh_token_" + character.CharacterName)
+	session, ok := sessions.Get(characterName)
 
-	if refreshToken == nil {
-		return ctx, errors.New("the refresh token does not exist")
+	if !ok {
+		return session, ErrUnknownCharacter
 	}
 
-	data.Add("grant_type", "refresh_token")
-	data.Add("refresh_token", refreshToken.(string))
-	data.Add("client_id", os.Getenv("ESI_CLIENT_ID"))
-
-	req, err := http.NewRequest(
-		"POST",
-		"https://login.eveonline.com/v2/oauth/token",
-		strings.NewReader(data.Encode()),
-	)
-
-	if err != nil {
-		return ctx, err
+	if session.RefreshToken == "" {
+		return session, errors.New("the refresh token does not exist")
 	}
 
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Host", "login.eveonline.com")
+	data.Add("grant_type", "refresh_token")
+	data.Add("refresh_token", session.RefreshToken)
+	data.Add("client_id", c.ClientID)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.postForm(c.ssoRoute("/v2/oauth/token"), data)
 
 	if err != nil {
-		return ctx, err
+		return session, err
 	}
 
+	defer res.Body.Close()
+
 	esiAuthResponse := ESIAuthResponse{}
 
 	err = ProcessBody(res.Body, &esiAuthResponse)
 
 	if err != nil {
-		return ctx, err
+		return session, err
+	}
+
+	if esiAuthResponse.Error == "invalid_grant" {
+		return session, ErrRefreshTokenRevoked
+	}
+
+	if esiAuthResponse.AccessToken == "" {
+		return session, errors.New("the token could not be refreshed: " + res.Status)
 	}
 
 	fmt.Println("refreshing")
 
-	ctx = context.WithValue(ctx, "access_token_"+character.CharacterName, esiAuthResponse.AccessToken)
-	ctx = context.WithValue(ctx, "refresh_token_"+character.CharacterName, esiAuthResponse.RefreshToken)
+	session.AccessToken = esiAuthResponse.AccessToken
+	session.RefreshToken = esiAuthResponse.RefreshToken
+	session.ExpiresAt = expiresAt(esiAuthResponse)
 
-	return ctx, nil
-}
+	// the SSO rotates the refresh token on every exchange and the old one is
+	// spent, so the new one is kept before the access token is validated
+	if !sessions.Update(session) {
+		// the character was removed while its token was refreshed, so the
+		// new refresh token is not needed either
+		err = c.RevokeToken(session.RefreshToken)
 
-func ProcessBody(body io.ReadCloser, s interface{}) error {
-	bytes, err := io.ReadAll(body)
+		if err != nil {
+			fmt.Println(err)
+		}
+
+		return session, ErrUnknownCharacter
+	}
+
+	character, err := c.GetCharacter(session.AccessToken)
 
 	if err != nil {
-		return err
+		return session, err
 	}
 
-	if err := json.Unmarshal(bytes, &s); err != nil {
-		return err
+	session.Character = character
+	session.Scopes = character.Scopes
+
+	if !sessions.Update(session) {
+		return session, ErrUnknownCharacter
 	}
 
-	return nil
+	return session, nil
 }
 
-func generateState() (string, error) {
-	bytes := make([]byte, 32)
-	_, err := rand.Read(bytes)
-	return base64url.Encode(bytes), err
-}
+// RevokeToken invalidates a refresh token at the SSO so it cannot be used
+// again.
+func (c *Client) RevokeToken(refreshToken string) error {
+	data := url.Values{}
 
-func fetchJWKS() (*JWKS, error) {
-	res, err := http.Get("https://login.eveonline.com/oauth/jwks")
+	data.Add("token_type_hint", "refresh_token")
+	data.Add("token", refreshToken)
+	data.Add("client_id", c.ClientID)
+
+	res, err := c.postForm(c.ssoRoute("/v2/oauth/revoke"), data)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer res.Body.Close()
 
-	var jwks JWKS
+	if res.StatusCode != http.StatusOK {
+		return errors.New("the refresh token could not be revoked: " + res.Status)
+	}
+
+	return nil
+}
+
+// expiresAt returns the earlier of expires_in and the exp claim of the access
+// token.
+func expiresAt(esiAuthResponse ESIAuthResponse) time.Time {
+	expiry := time.Now().Add(time.Duration(esiAuthResponse.ExpiresIn) * time.Second)
 
-	err = ProcessBody(res.Body, &jwks)
+	token, _, err := jwt.NewParser().ParseUnverified(esiAuthResponse.AccessToken, jwt.MapClaims{})
 
 	if err != nil {
-		return nil, err
+		return expiry
 	}
 
-	return &jwks, nil
-}
+	exp, err := token.Claims.GetExpirationTime()
 
-func extractAndValidateKey(token *jwt.Token, jwks *JWKS) (*rsa.PublicKey, error) {
-	kid := token.Header["kid"].(string)
+	if err != nil || exp == nil {
+		return expiry
+	}
 
-	for _, key := range jwks.Keys {
-		if key.Kid == kid && key.Kty == "RSA" && key.Use == "sig" {
-			modulus, err := base64url.Decode(key.N)
+	if exp.Time.Before(expiry) {
+		return exp.Time
+	}
 
-			if err != nil {
-				return nil, err
-			}
+	return expiry
+}
 
-			exponent, err := base64url.Decode(key.E)
+func ProcessBody(body io.ReadCloser, s interface{}) error {
+	bytes, err := io.ReadAll(body)
 
-			if err != nil {
-				return nil, err
-			}
+	if err != nil {
+		return err
+	}
 
-			rsaPublicKey := &rsa.PublicKey{
-				N: new(big.Int).SetBytes(modulus),
-				E: int(new(big.Int).SetBytes(exponent).Int64()),
-			}
-			return rsaPublicKey, nil
-		}
+	if err := json.Unmarshal(bytes, &s); err != nil {
+		return err
 	}
 
-	return nil, fmt.Errorf("unable to find RSA public key for kid: %s", kid)
+	return nil
 }
@@ -0,0 +1,48 @@
+package eve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDescribeSystemRemembersESI(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		switch r.URL.Path {
+		case "/universe/systems/39999999/":
+			w.Write([]byte(`{"name":"Test System","security_status":0.46,"constellation_id":29999999}`))
+		case "/universe/constellations/29999999/":
+			w.Write([]byte(`{"name":"Test Constellation","region_id":19999999}`))
+		case "/universe/regions/19999999/":
+			w.Write([]byte(`{"name":"Test Region"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer server.Close()
+
+	client := NewClient()
+	client.ESIURL = server.URL
+
+	for i := 0; i < 3; i++ {
+		location, err := client.describeSystem(39999999)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if location.Name != "Test System" || location.Constellation != "Test Constellation" || location.Region != "Test Region" {
+			t.Errorf("describeSystem() = %+v", location)
+		}
+	}
+
+	if n := requests.Load(); n != 3 {
+		t.Errorf("ESI was asked %d times, want 3 for the first lookup only", n)
+	}
+}
@@ -0,0 +1,270 @@
+package eve
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultHTTPCacheEntries = 2048
+
+type cacheCharacterKey struct{}
+
+type HTTPCacheStats struct {
+	Hits        int     `json:"hits"`
+	Revalidated int     `json:"revalidated"`
+	Misses      int     `json:"misses"`
+	Entries     int     `json:"entries"`
+	HitRatio    float64 `json:"hit_ratio"`
+}
+
+// CachingTransport serves GET responses from memory until their Expires (or
+// Cache-Control max-age) passes and then revalidates them with
+// If-None-Match. Authenticated responses are keyed by character as well as
+// URL.
+type CachingTransport struct {
+	Transport  http.RoundTripper
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*httpCacheEntry
+	stats   HTTPCacheStats
+}
+
+type httpCacheEntry struct {
+	status  int
+	header  http.Header
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+func NewCachingTransport(transport http.RoundTripper) *CachingTransport {
+	return &CachingTransport{
+		Transport:  transport,
+		MaxEntries: defaultHTTPCacheEntries,
+		entries:    make(map[string]*httpCacheEntry),
+	}
+}
+
+// withCacheCharacter marks a request as made on behalf of a character so its
+// response is cached separately from other characters.
+func withCacheCharacter(ctx context.Context, characterID int64) context.Context {
+	return context.WithValue(ctx, cacheCharacterKey{}, characterID)
+}
+
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.Transport.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	now := time.Now()
+
+	t.mu.Lock()
+	entry, ok := t.entries[key]
+
+	if ok && now.Before(entry.expires) {
+		t.stats.Hits++
+		t.mu.Unlock()
+
+		return entry.response(req), nil
+	}
+
+	t.mu.Unlock()
+
+	if ok && entry.etag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+
+	res, err := t.Transport.RoundTrip(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+
+		t.mu.Lock()
+		entry.expires = freshUntil(res.Header, now)
+		t.stats.Revalidated++
+		t.mu.Unlock()
+
+		cached := entry.response(req)
+
+		// The error budget comes from the 304, not from the stored response.
+		for name, values := range res.Header {
+			if errorLimitHeader(name) {
+				cached.Header[name] = values
+			}
+		}
+
+		return cached, nil
+	}
+
+	t.mu.Lock()
+	t.stats.Misses++
+	t.mu.Unlock()
+
+	if res.StatusCode != http.StatusOK || !cacheable(res.Header) {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+
+	res.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := res.Header.Clone()
+
+	for name := range header {
+		if errorLimitHeader(name) {
+			header.Del(name)
+		}
+	}
+
+	t.store(key, &httpCacheEntry{
+		status:  res.StatusCode,
+		header:  header,
+		body:    body,
+		etag:    res.Header.Get("ETag"),
+		expires: freshUntil(res.Header, now),
+	})
+
+	return res, nil
+}
+
+// Expires returns when the cached response for req stops being fresh.
+func (t *CachingTransport) Expires(req *http.Request) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[cacheKey(req)]
+
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return entry.expires, true
+}
+
+func (t *CachingTransport) Stats() HTTPCacheStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats
+	stats.Entries = len(t.entries)
+
+	total := stats.Hits + stats.Revalidated + stats.Misses
+
+	if total > 0 {
+		stats.HitRatio = float64(stats.Hits+stats.Revalidated) / float64(total)
+	}
+
+	return stats
+}
+
+func (t *CachingTransport) store(key string, entry *httpCacheEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entries[key] = entry
+
+	if len(t.entries) <= t.MaxEntries {
+		return
+	}
+
+	now := time.Now()
+
+	for k, e := range t.entries {
+		if e.etag == "" && now.After(e.expires) {
+			delete(t.entries, k)
+		}
+	}
+
+	for len(t.entries) > t.MaxEntries {
+		var oldestKey string
+		var oldest time.Time
+
+		for k, e := range t.entries {
+			if oldestKey == "" || e.expires.Before(oldest) {
+				oldestKey = k
+				oldest = e.expires
+			}
+		}
+
+		delete(t.entries, oldestKey)
+	}
+}
+
+func (e *httpCacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+func cacheKey(req *http.Request) string {
+	key := req.URL.String()
+
+	if characterID, ok := req.Context().Value(cacheCharacterKey{}).(int64); ok {
+		key = strconv.FormatInt(characterID, 10) + " " + key
+	}
+
+	return key
+}
+
+func errorLimitHeader(name string) bool {
+	return strings.HasPrefix(http.CanonicalHeaderKey(name), "X-Esi-Error-Limit")
+}
+
+func cacheable(header http.Header) bool {
+	cacheControl := strings.ToLower(header.Get("Cache-Control"))
+
+	if strings.Contains(cacheControl, "no-store") {
+		return false
+	}
+
+	return header.Get("ETag") != "" || header.Get("Expires") != "" || strings.Contains(cacheControl, "max-age")
+}
+
+func freshUntil(header http.Header, now time.Time) time.Time {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+
+		if directive == "no-cache" {
+			return now
+		}
+
+		if maxAge, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if seconds, err := strconv.Atoi(maxAge); err == nil {
+				return now.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return expires
+	}
+
+	return now
+}
@@ -0,0 +1,157 @@
+package eve
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func testResponse(status int, header http.Header, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestFreshUntil(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Time
+	}{
+		{name: "no headers", header: http.Header{}, want: now},
+		{name: "max-age", header: http.Header{"Cache-Control": {"public, max-age=300"}}, want: now.Add(5 * time.Minute)},
+		{name: "no-cache", header: http.Header{"Cache-Control": {"no-cache, max-age=300"}}, want: now},
+		{name: "expires", header: http.Header{"Expires": {"Wed, 01 May 2024 12:10:00 GMT"}}, want: now.Add(10 * time.Minute)},
+		{
+			name:   "max-age before expires",
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Expires": {"Wed, 01 May 2024 12:10:00 GMT"}},
+			want:   now.Add(time.Minute),
+		},
+		{name: "invalid expires", header: http.Header{"Expires": {"0"}}, want: now},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := freshUntil(test.header, now)
+
+			if !got.Equal(test.want) {
+				t.Errorf("freshUntil() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCachingTransportServesFreshResponses(t *testing.T) {
+	calls := 0
+
+	transport := NewCachingTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+
+		return testResponse(http.StatusOK, http.Header{"Cache-Control": {"max-age=60"}}, "fresh"), nil
+	}))
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "https://esi.example/latest/status/", nil)
+
+		res, err := transport.RoundTrip(req)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(res.Body)
+
+		if string(body) != "fresh" {
+			t.Errorf("body = %q, want %q", body, "fresh")
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("the upstream was called %d times, want 1", calls)
+	}
+
+	stats := transport.Stats()
+
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Stats() = %+v, want 1 hit and 1 miss", stats)
+	}
+}
+
+func TestCachingTransportRevalidates(t *testing.T) {
+	var ifNoneMatch []string
+
+	transport := NewCachingTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		ifNoneMatch = append(ifNoneMatch, req.Header.Get("If-None-Match"))
+
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return testResponse(http.StatusNotModified, http.Header{
+				"Cache-Control":            {"max-age=60"},
+				"X-Esi-Error-Limit-Remain": {"80"},
+			}, ""), nil
+		}
+
+		return testResponse(http.StatusOK, http.Header{
+			"Etag":                     {`"v1"`},
+			"Cache-Control":            {"no-cache"},
+			"X-Esi-Error-Limit-Remain": {"100"},
+		}, "cached"), nil
+	}))
+
+	get := func() *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, "https://esi.example/latest/universe/systems/30000142/", nil)
+
+		res, err := transport.RoundTrip(req)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	get()
+	res := get()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("the revalidated status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+
+	body, _ := io.ReadAll(res.Body)
+
+	if string(body) != "cached" {
+		t.Errorf("the revalidated body = %q, want %q", body, "cached")
+	}
+
+	if remain := res.Header.Get("X-Esi-Error-Limit-Remain"); remain != "80" {
+		t.Errorf("the error limit = %q, want the 304's %q", remain, "80")
+	}
+
+	if len(ifNoneMatch) != 2 || ifNoneMatch[0] != "" || ifNoneMatch[1] != `"v1"` {
+		t.Errorf("If-None-Match headers = %q", ifNoneMatch)
+	}
+
+	// the 304 made the entry fresh again, so the third request stays local
+	get()
+
+	if len(ifNoneMatch) != 2 {
+		t.Errorf("the upstream was called %d times, want 2", len(ifNoneMatch))
+	}
+
+	stats := transport.Stats()
+
+	if stats.Revalidated != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Stats() = %+v, want 1 revalidation, 1 hit and 1 miss", stats)
+	}
+}
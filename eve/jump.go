@@ -0,0 +1,419 @@
+package eve
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"eve-chaperone/eve/universe"
+)
+
+// LightYear is a light year in metres, the unit of the static map.
+const LightYear = 9.4607e15
+
+type JumpShip string
+
+const (
+	JumpCarrier        JumpShip = "carrier"
+	JumpDreadnought    JumpShip = "dreadnought"
+	JumpForceAuxiliary JumpShip = "force-auxiliary"
+	JumpSupercarrier   JumpShip = "supercarrier"
+	JumpTitan          JumpShip = "titan"
+	JumpBlackOps       JumpShip = "black-ops"
+	JumpFreighter      JumpShip = "jump-freighter"
+	JumpRorqual        JumpShip = "rorqual"
+)
+
+// Jump Drive Calibration adds 20% of the base range per level.
+const jumpCalibrationBonus = 0.2
+
+const (
+	minJumpFatigue    = 10 * time.Minute
+	maxJumpFatigue    = 5 * time.Hour
+	maxJumpActivation = 30 * time.Minute
+	jumpLegWeight     = 1000
+)
+
+var baseJumpRanges = map[JumpShip]float64{
+	JumpCarrier:        3.5,
+	JumpDreadnought:    3.5,
+	JumpForceAuxiliary: 3.5,
+	JumpSupercarrier:   3,
+	JumpTitan:          3,
+	JumpBlackOps:       4,
+	JumpFreighter:      5,
+	JumpRorqual:        5,
+}
+
+// jumpFatigueReductions are the role bonuses that shorten the distance
+// counted for fatigue.
+var jumpFatigueReductions = map[JumpShip]float64{
+	JumpBlackOps:  0.75,
+	JumpFreighter: 0.9,
+	JumpRorqual:   0.9,
+}
+
+var ErrUnknownJumpShip = errors.New("unknown jump capable ship")
+
+var ErrJumpOrigin = errors.New("jump drives cannot be activated in highsec, J-space or Pochven")
+
+var ErrJumpDestination = errors.New("jump drives cannot jump into highsec, J-space or Pochven")
+
+var ErrNoJumpRoute = errors.New("no jump route")
+
+type JumpOptions struct {
+	Ship JumpShip `json:"ship"`
+	// CalibrationLevel is the pilot's Jump Drive Calibration skill, 0 to 5.
+	CalibrationLevel int `json:"calibration_level"`
+	// FatigueMinutes is the jump fatigue the pilot already has.
+	FatigueMinutes float64  `json:"fatigue_minutes"`
+	Avoid          []string `json:"avoid"`
+}
+
+// JumpCheck tells whether a ship can jump straight from one system to
+// another.
+type JumpCheck struct {
+	LightYears float64 `json:"light_years"`
+	Range      float64 `json:"range"`
+	InRange    bool    `json:"in_range"`
+	Reason     string  `json:"reason"`
+}
+
+// JumpLeg is one jump of a plan. The times are in minutes from the first
+// jump; fatigue and the activation timer are the ones the jump leaves behind.
+type JumpLeg struct {
+	From                RouteHop `json:"from"`
+	To                  RouteHop `json:"to"`
+	LightYears          float64  `json:"light_years"`
+	EffectiveLightYears float64  `json:"effective_light_years"`
+	DepartMinutes       float64  `json:"depart_minutes"`
+	FatigueMinutes      float64  `json:"fatigue_minutes"`
+	ActivationMinutes   float64  `json:"activation_minutes"`
+}
+
+type JumpPlan struct {
+	Ship                JumpShip  `json:"ship"`
+	Range               float64   `json:"range"`
+	Legs                []JumpLeg `json:"legs"`
+	LightYears          float64   `json:"light_years"`
+	TotalMinutes        float64   `json:"total_minutes"`
+	FinalFatigueMinutes float64   `json:"final_fatigue_minutes"`
+}
+
+// JumpRange returns the range of a ship in light years.
+func JumpRange(ship JumpShip, calibrationLevel int) (float64, error) {
+	base, ok := baseJumpRanges[ship]
+
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownJumpShip, ship)
+	}
+
+	level := min(max(calibrationLevel, 0), 5)
+
+	return base * (1 + jumpCalibrationBonus*float64(level)), nil
+}
+
+// LightYears is the straight line distance between two systems.
+func LightYears(a universe.System, b universe.System) float64 {
+	return math.Sqrt((a.X-b.X)*(a.X-b.X)+(a.Y-b.Y)*(a.Y-b.Y)+(a.Z-b.Z)*(a.Z-b.Z)) / LightYear
+}
+
+// CynoCapable tells whether a jump drive can jump into, or out of, a system.
+func CynoCapable(system universe.System) bool {
+	return system.SpaceType == universe.Lowsec || system.SpaceType == universe.Nullsec
+}
+
+// JumpPlanner plans capital jumps over the static map.
+type JumpPlanner struct {
+	graph   *StargateGraph
+	targets []universe.System
+}
+
+// NewJumpPlanner plans over the systems of a stargate graph's map.
+func NewJumpPlanner(graph *StargateGraph) *JumpPlanner {
+	planner := &JumpPlanner{graph: graph}
+
+	for _, system := range graph.universe.Systems() {
+		if CynoCapable(system) {
+			planner.targets = append(planner.targets, system)
+		}
+	}
+
+	return planner
+}
+
+func (p *JumpPlanner) Check(from string, to string, options JumpOptions) (JumpCheck, error) {
+	start, err := p.graph.System(from)
+
+	if err != nil {
+		return JumpCheck{}, err
+	}
+
+	end, err := p.graph.System(to)
+
+	if err != nil {
+		return JumpCheck{}, err
+	}
+
+	jumpRange, err := JumpRange(options.Ship, options.CalibrationLevel)
+
+	if err != nil {
+		return JumpCheck{}, err
+	}
+
+	check := JumpCheck{
+		LightYears: LightYears(start, end),
+		Range:      jumpRange,
+	}
+
+	switch {
+	case !CynoCapable(start):
+		check.Reason = fmt.Sprintf("%s is %s", start.Name, start.SpaceType)
+	case !CynoCapable(end):
+		check.Reason = fmt.Sprintf("%s is %s", end.Name, end.SpaceType)
+	case check.LightYears > jumpRange:
+		check.Reason = fmt.Sprintf("%.2f LY is beyond the %.2f LY range", check.LightYears, jumpRange)
+	default:
+		check.InRange = true
+	}
+
+	return check, nil
+}
+
+// Plan finds the jump route with the fewest jumps, and of those the shortest,
+// and projects the fatigue and activation timers of jumping each leg as soon
+// as the previous activation timer runs out.
+func (p *JumpPlanner) Plan(from string, to string, options JumpOptions) (JumpPlan, error) {
+	start, err := p.graph.System(from)
+
+	if err != nil {
+		return JumpPlan{}, err
+	}
+
+	end, err := p.graph.System(to)
+
+	if err != nil {
+		return JumpPlan{}, err
+	}
+
+	if !CynoCapable(start) {
+		return JumpPlan{}, fmt.Errorf("%w: %s is %s", ErrJumpOrigin, start.Name, start.SpaceType)
+	}
+
+	if !CynoCapable(end) {
+		return JumpPlan{}, fmt.Errorf("%w: %s is %s", ErrJumpDestination, end.Name, end.SpaceType)
+	}
+
+	jumpRange, err := JumpRange(options.Ship, options.CalibrationLevel)
+
+	if err != nil {
+		return JumpPlan{}, err
+	}
+
+	avoid := make(map[int64]bool)
+
+	for _, name := range options.Avoid {
+		system, err := p.graph.System(name)
+
+		if err != nil {
+			return JumpPlan{}, err
+		}
+
+		avoid[system.Id] = true
+	}
+
+	path, ok := p.shortestJumps(start, end, jumpRange, avoid)
+
+	if !ok {
+		return JumpPlan{}, fmt.Errorf("%w from %s to %s within %.2f LY", ErrNoJumpRoute, start.Name, end.Name, jumpRange)
+	}
+
+	plan := JumpPlan{
+		Ship:  options.Ship,
+		Range: jumpRange,
+	}
+
+	distances := make([]float64, 0, len(path)-1)
+
+	for i := 1; i < len(path); i++ {
+		distances = append(distances, LightYears(path[i-1], path[i]))
+	}
+
+	timers := ProjectFatigue(options.Ship, distances, time.Duration(options.FatigueMinutes*float64(time.Minute)))
+
+	for i, timer := range timers {
+		plan.Legs = append(plan.Legs, JumpLeg{
+			From:                routeHop(path[i]),
+			To:                  routeHop(path[i+1]),
+			LightYears:          distances[i],
+			EffectiveLightYears: timer.EffectiveLightYears,
+			DepartMinutes:       timer.Depart.Minutes(),
+			FatigueMinutes:      timer.Fatigue.Minutes(),
+			ActivationMinutes:   timer.Activation.Minutes(),
+		})
+
+		plan.LightYears += distances[i]
+	}
+
+	if len(timers) > 0 {
+		last := timers[len(timers)-1]
+
+		plan.TotalMinutes = last.Depart.Minutes()
+		plan.FinalFatigueMinutes = last.Fatigue.Minutes()
+	}
+
+	return plan, nil
+}
+
+// JumpTimer is the state a jump leaves the pilot in. Depart is when the jump
+// is made, counted from the first jump.
+type JumpTimer struct {
+	EffectiveLightYears float64
+	Depart              time.Duration
+	Fatigue             time.Duration
+	Activation          time.Duration
+}
+
+// ProjectFatigue projects the timers of a sequence of jumps of the given
+// lengths, each made as soon as the previous activation timer runs out.
+// Fatigue wears off in real time between jumps.
+func ProjectFatigue(ship JumpShip, distances []float64, fatigue time.Duration) []JumpTimer {
+	timers := make([]JumpTimer, 0, len(distances))
+
+	var depart time.Duration
+
+	for i, distance := range distances {
+		if i > 0 {
+			wait := timers[i-1].Activation
+
+			depart += wait
+			fatigue = max(fatigue-wait, 0)
+		}
+
+		effective := distance * (1 - jumpFatigueReductions[ship])
+
+		activation := max(time.Duration((1+effective)*float64(time.Minute)), fatigue/10)
+
+		fatigue = min(time.Duration(float64(max(fatigue, minJumpFatigue))*(1+effective)), maxJumpFatigue)
+
+		timers = append(timers, JumpTimer{
+			EffectiveLightYears: effective,
+			Depart:              depart,
+			Fatigue:             fatigue,
+			Activation:          min(activation, maxJumpActivation),
+		})
+	}
+
+	return timers
+}
+
+// shortestJumps runs Dijkstra over the cyno capable systems in range of each
+// other, weighing every jump far above the light years it covers.
+func (p *JumpPlanner) shortestJumps(start universe.System, end universe.System, jumpRange float64, avoid map[int64]bool) ([]universe.System, bool) {
+	systems := map[int64]universe.System{start.Id: start}
+	dist := map[int64]float64{start.Id: 0}
+	prev := make(map[int64]int64)
+	done := make(map[int64]bool)
+
+	queue := &jumpQueue{{system: start.Id}}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(jumpItem)
+
+		if done[item.system] {
+			continue
+		}
+
+		done[item.system] = true
+
+		if item.system == end.Id {
+			break
+		}
+
+		current := systems[item.system]
+
+		for _, next := range p.targets {
+			if done[next.Id] || (avoid[next.Id] && next.Id != end.Id) {
+				continue
+			}
+
+			distance := LightYears(current, next)
+
+			if distance > jumpRange {
+				continue
+			}
+
+			cost := item.cost + jumpLegWeight + distance
+
+			if known, ok := dist[next.Id]; ok && known <= cost {
+				continue
+			}
+
+			systems[next.Id] = next
+			dist[next.Id] = cost
+			prev[next.Id] = item.system
+
+			heap.Push(queue, jumpItem{system: next.Id, cost: cost})
+		}
+	}
+
+	if !done[end.Id] {
+		return nil, false
+	}
+
+	path := []universe.System{end}
+
+	for id := end.Id; id != start.Id; {
+		id = prev[id]
+
+		path = append(path, systems[id])
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, true
+}
+
+func routeHop(system universe.System) RouteHop {
+	return RouteHop{
+		SystemId:        system.Id,
+		Name:            system.Name,
+		Security:        system.Security,
+		RoundedSecurity: system.RoundedSecurity,
+		SpaceType:       system.SpaceType,
+		Region:          system.Region,
+	}
+}
+
+type jumpItem struct {
+	system int64
+	cost   float64
+}
+
+type jumpQueue []jumpItem
+
+func (q jumpQueue) Len() int { return len(q) }
+
+func (q jumpQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+
+	return q[i].system < q[j].system
+}
+
+func (q jumpQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *jumpQueue) Push(x interface{}) { *q = append(*q, x.(jumpItem)) }
+
+func (q *jumpQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return item
+}
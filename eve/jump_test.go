@@ -0,0 +1,182 @@
+package eve
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJumpRange(t *testing.T) {
+	tests := []struct {
+		ship    JumpShip
+		level   int
+		want    float64
+		wantErr error
+	}{
+		{ship: JumpCarrier, level: 0, want: 3.5},
+		{ship: JumpCarrier, level: 5, want: 7},
+		{ship: JumpTitan, level: 3, want: 4.8},
+		{ship: JumpFreighter, level: 4, want: 9},
+		{ship: JumpBlackOps, level: -1, want: 4},
+		{ship: JumpBlackOps, level: 9, want: 8},
+		{ship: "shuttle", wantErr: ErrUnknownJumpShip},
+	}
+
+	for _, test := range tests {
+		got, err := JumpRange(test.ship, test.level)
+
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("JumpRange(%s, %d) error = %v, want %v", test.ship, test.level, err, test.wantErr)
+
+			continue
+		}
+
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("JumpRange(%s, %d) = %v, want %v", test.ship, test.level, got, test.want)
+		}
+	}
+}
+
+func TestProjectFatigue(t *testing.T) {
+	tests := []struct {
+		name      string
+		ship      JumpShip
+		distances []float64
+		fatigue   time.Duration
+		want      []JumpTimer
+	}{
+		{
+			name:      "fresh pilot",
+			ship:      JumpCarrier,
+			distances: []float64{4, 4},
+			want: []JumpTimer{
+				{EffectiveLightYears: 4, Depart: 0, Fatigue: 50 * time.Minute, Activation: 5 * time.Minute},
+				// 5 minutes of the 50 wear off before the second jump
+				{EffectiveLightYears: 4, Depart: 5 * time.Minute, Fatigue: 225 * time.Minute, Activation: 5 * time.Minute},
+			},
+		},
+		{
+			name:      "role bonus",
+			ship:      JumpFreighter,
+			distances: []float64{5},
+			want: []JumpTimer{
+				{EffectiveLightYears: 0.5, Fatigue: 15 * time.Minute, Activation: 90 * time.Second},
+			},
+		},
+		{
+			name:      "caps",
+			ship:      JumpCarrier,
+			distances: []float64{5},
+			fatigue:   6 * time.Hour,
+			want: []JumpTimer{
+				{EffectiveLightYears: 5, Fatigue: maxJumpFatigue, Activation: maxJumpActivation},
+			},
+		},
+	}
+
+	closeTo := func(a, b time.Duration) bool {
+		return (a - b).Abs() < time.Millisecond
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ProjectFatigue(test.ship, test.distances, test.fatigue)
+
+			if len(got) != len(test.want) {
+				t.Fatalf("ProjectFatigue() = %+v, want %+v", got, test.want)
+			}
+
+			for i, want := range test.want {
+				timer := got[i]
+
+				if math.Abs(timer.EffectiveLightYears-want.EffectiveLightYears) > 1e-9 ||
+					!closeTo(timer.Depart, want.Depart) ||
+					!closeTo(timer.Fatigue, want.Fatigue) ||
+					!closeTo(timer.Activation, want.Activation) {
+					t.Errorf("jump %d = %+v, want %+v", i, timer, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJumpPlannerPlan(t *testing.T) {
+	planner := NewJumpPlanner(NewStargateGraph(loadTestUniverse(t)))
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		options JumpOptions
+		want    []string
+		wantErr error
+	}{
+		{name: "midpoint", from: "Delta", to: "Foxtrot", options: JumpOptions{Ship: JumpCarrier, CalibrationLevel: 5}, want: []string{"Delta", "Echo", "Foxtrot"}},
+		{name: "direct", from: "Delta", to: "Foxtrot", options: JumpOptions{Ship: JumpFreighter, CalibrationLevel: 5}, want: []string{"Delta", "Foxtrot"}},
+		{name: "out of range", from: "Delta", to: "Foxtrot", options: JumpOptions{Ship: JumpCarrier}, wantErr: ErrNoJumpRoute},
+		{name: "avoided midpoint", from: "Delta", to: "Foxtrot", options: JumpOptions{Ship: JumpCarrier, CalibrationLevel: 5, Avoid: []string{"Echo"}}, wantErr: ErrNoJumpRoute},
+		{name: "highsec origin", from: "Alpha", to: "Delta", options: JumpOptions{Ship: JumpCarrier}, wantErr: ErrJumpOrigin},
+		{name: "highsec destination", from: "Delta", to: "Golf", options: JumpOptions{Ship: JumpCarrier}, wantErr: ErrJumpDestination},
+		{name: "unknown ship", from: "Delta", to: "Echo", options: JumpOptions{Ship: "shuttle"}, wantErr: ErrUnknownJumpShip},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan, err := planner.Plan(test.from, test.to, test.options)
+
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Plan() error = %v, want %v", err, test.wantErr)
+			}
+
+			if test.wantErr != nil {
+				return
+			}
+
+			var got []string
+
+			for i, leg := range plan.Legs {
+				if i == 0 {
+					got = append(got, leg.From.Name)
+				}
+
+				got = append(got, leg.To.Name)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Plan() jumps through %v, want %v", got, test.want)
+			}
+
+			if math.Abs(plan.LightYears-8) > 1e-9 {
+				t.Errorf("Plan() covers %v LY, want 8", plan.LightYears)
+			}
+
+			last := plan.Legs[len(plan.Legs)-1]
+
+			if plan.TotalMinutes != last.DepartMinutes || plan.FinalFatigueMinutes != last.FatigueMinutes {
+				t.Errorf("Plan() totals %+v do not match the last leg %+v", plan, last)
+			}
+		})
+	}
+}
+
+func TestJumpPlannerCheck(t *testing.T) {
+	planner := NewJumpPlanner(NewStargateGraph(loadTestUniverse(t)))
+
+	check, err := planner.Check("Delta", "Foxtrot", JumpOptions{Ship: JumpCarrier, CalibrationLevel: 5})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if check.InRange || math.Abs(check.LightYears-8) > 1e-9 || check.Range != 7 || check.Reason == "" {
+		t.Errorf("Check() = %+v, want 8 LY out of the 7 LY range", check)
+	}
+
+	check, err = planner.Check("Delta", "Echo", JumpOptions{Ship: JumpCarrier, CalibrationLevel: 5})
+
+	if err != nil || !check.InRange {
+		t.Errorf("Check() = %+v, %v, want Echo in range", check, err)
+	}
+}
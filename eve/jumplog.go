@@ -0,0 +1,469 @@
+package eve
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type JumpLogKind string
+
+const (
+	JumpLogJump   JumpLogKind = "jump"
+	JumpLogDock   JumpLogKind = "dock"
+	JumpLogUndock JumpLogKind = "undock"
+)
+
+// DefaultJumpLogRetention is how long jump log entries are kept.
+const DefaultJumpLogRetention = 90 * 24 * time.Hour
+
+// JumpLogEntry is one system change, dock or undock. Jumps name the system
+// that was left, unless it is the first location seen of the character; docks
+// and undocks name the station or structure.
+type JumpLogEntry struct {
+	Time         time.Time   `json:"time"`
+	Character    string      `json:"character"`
+	Kind         JumpLogKind `json:"kind"`
+	SystemId     int         `json:"system_id"`
+	System       string      `json:"system"`
+	FromSystemId int         `json:"from_system_id,omitempty"`
+	FromSystem   string      `json:"from_system,omitempty"`
+	StationId    int         `json:"station_id,omitempty"`
+	StructureId  int         `json:"structure_id,omitempty"`
+	ShipType     string      `json:"ship_type,omitempty"`
+}
+
+// SystemTime is how long a character stayed in a system and how many times it
+// arrived there.
+type SystemTime struct {
+	SystemId int     `json:"system_id"`
+	System   string  `json:"system"`
+	Minutes  float64 `json:"minutes"`
+	Visits   int     `json:"visits"`
+}
+
+// JumpLog records the location changes of every character. An opened log
+// appends each entry as a JSON line to its file. It is safe for concurrent
+// use.
+type JumpLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string][]JumpLogEntry
+}
+
+// NewJumpLog returns a log kept in memory only.
+func NewJumpLog() *JumpLog {
+	return &JumpLog{
+		entries: make(map[string][]JumpLogEntry),
+	}
+}
+
+// OpenJumpLog loads the log at path, dropping entries older than retention,
+// and appends every new entry to it.
+func OpenJumpLog(path string, retention time.Duration) (*JumpLog, error) {
+	l := NewJumpLog()
+
+	dropped, err := l.load(path, time.Now().Add(-retention))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if dropped {
+		err = l.rewrite(path)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.file = file
+
+	return l, nil
+}
+
+// Record compares a character's location with the last one logged and logs
+// the undock, jump and dock that lead from one to the other.
+func (l *JumpLog) Record(character string, location LocationResponse, at time.Time) ([]JumpLogEntry, error) {
+	if location.SolarSystemId == 0 {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var last JumpLogEntry
+
+	known := len(l.entries[character]) > 0
+
+	if known {
+		last = l.entries[character][len(l.entries[character])-1]
+	}
+
+	moved := !known || last.SystemId != location.SolarSystemId
+	wasDocked := known && last.Kind == JumpLogDock
+	docked := location.StationId != 0 || location.StructureId != 0
+	left := wasDocked && (moved || last.StationId != location.StationId || last.StructureId != location.StructureId)
+
+	var entries []JumpLogEntry
+
+	if left {
+		entries = append(entries, JumpLogEntry{
+			Kind:        JumpLogUndock,
+			SystemId:    last.SystemId,
+			System:      last.System,
+			StationId:   last.StationId,
+			StructureId: last.StructureId,
+		})
+	}
+
+	if moved {
+		entry := JumpLogEntry{
+			Kind:     JumpLogJump,
+			SystemId: location.SolarSystemId,
+			System:   location.Name,
+		}
+
+		if known {
+			entry.FromSystemId = last.SystemId
+			entry.FromSystem = last.System
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if docked && (!wasDocked || left) {
+		entries = append(entries, JumpLogEntry{
+			Kind:        JumpLogDock,
+			SystemId:    location.SolarSystemId,
+			System:      location.Name,
+			StationId:   location.StationId,
+			StructureId: location.StructureId,
+		})
+	}
+
+	for i := range entries {
+		entries[i].Time = at
+		entries[i].Character = character
+		entries[i].ShipType = location.ShipType
+
+		err := l.append(entries[i])
+
+		if err != nil {
+			return entries[:i], err
+		}
+
+		l.entries[character] = append(l.entries[character], entries[i])
+	}
+
+	return entries, nil
+}
+
+// Entries returns the entries since a time, oldest first. An empty character
+// returns the entries of every character.
+func (l *JumpLog) Entries(character string, since time.Time) []JumpLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []JumpLogEntry
+
+	for name, logged := range l.entries {
+		if character != "" && name != character {
+			continue
+		}
+
+		for _, entry := range logged {
+			if !entry.Time.Before(since) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Time.Equal(entries[j].Time) {
+			return entries[i].Character < entries[j].Character
+		}
+
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	return entries
+}
+
+// LastJumps returns the last n jumps of a character, newest first.
+func (l *JumpLog) LastJumps(character string, n int) []JumpLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	logged := l.entries[character]
+
+	var jumps []JumpLogEntry
+
+	for i := len(logged) - 1; i >= 0 && len(jumps) < n; i-- {
+		if logged[i].Kind == JumpLogJump {
+			jumps = append(jumps, logged[i])
+		}
+	}
+
+	return jumps
+}
+
+// Route returns the jumps of a character since a time, led by the jump into
+// the system it was in at that time.
+func (l *JumpLog) Route(character string, since time.Time) []JumpLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var route []JumpLogEntry
+
+	for _, entry := range l.entries[character] {
+		if entry.Kind != JumpLogJump {
+			continue
+		}
+
+		if entry.Time.Before(since) {
+			route = append(route[:0], entry)
+
+			continue
+		}
+
+		route = append(route, entry)
+	}
+
+	return route
+}
+
+// TimePerSystem adds up how long a character stayed in each system between
+// since and now, longest first. The last system counts until now.
+func (l *JumpLog) TimePerSystem(character string, since time.Time, now time.Time) []SystemTime {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	logged := l.entries[character]
+
+	totals := make(map[int]*SystemTime)
+
+	for i, entry := range logged {
+		start := entry.Time
+		end := now
+
+		if i+1 < len(logged) {
+			end = logged[i+1].Time
+		}
+
+		if start.Before(since) {
+			start = since
+		}
+
+		if end.After(now) {
+			end = now
+		}
+
+		arrived := entry.Kind == JumpLogJump && !entry.Time.Before(since) && !entry.Time.After(now)
+
+		if !arrived && !end.After(start) {
+			continue
+		}
+
+		total, ok := totals[entry.SystemId]
+
+		if !ok {
+			total = &SystemTime{SystemId: entry.SystemId, System: entry.System}
+
+			totals[entry.SystemId] = total
+		}
+
+		// a stay that began before since still counts as a visit
+		if arrived || !ok {
+			total.Visits++
+		}
+
+		if end.After(start) {
+			total.Minutes += end.Sub(start).Minutes()
+		}
+	}
+
+	times := make([]SystemTime, 0, len(totals))
+
+	for _, total := range totals {
+		times = append(times, *total)
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		if times[i].Minutes != times[j].Minutes {
+			return times[i].Minutes > times[j].Minutes
+		}
+
+		return times[i].System < times[j].System
+	})
+
+	return times
+}
+
+func (l *JumpLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+
+	l.file = nil
+
+	return err
+}
+
+// WriteJumpLogCSV writes entries as CSV with a header row.
+func WriteJumpLogCSV(w io.Writer, entries []JumpLogEntry) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{
+		"time", "character", "kind", "system_id", "system", "from_system_id",
+		"from_system", "station_id", "structure_id", "ship_type",
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err = writer.Write([]string{
+			entry.Time.UTC().Format(time.RFC3339),
+			entry.Character,
+			string(entry.Kind),
+			strconv.Itoa(entry.SystemId),
+			entry.System,
+			optionalId(entry.FromSystemId),
+			entry.FromSystem,
+			optionalId(entry.StationId),
+			optionalId(entry.StructureId),
+			entry.ShipType,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteJumpLogJSON writes entries as an indented JSON array.
+func WriteJumpLogJSON(w io.Writer, entries []JumpLogEntry) error {
+	if entries == nil {
+		entries = []JumpLogEntry{}
+	}
+
+	encoder := json.NewEncoder(w)
+
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(entries)
+}
+
+func optionalId(id int) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(id)
+}
+
+func (l *JumpLog) append(entry JumpLogEntry) error {
+	if l.file == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = l.file.Write(append(line, '\n'))
+
+	return err
+}
+
+// load reads the log at path, skipping corrupt lines, and reports whether
+// entries older than cutoff were dropped.
+func (l *JumpLog) load(path string, cutoff time.Time) (bool, error) {
+	file, err := os.Open(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	defer file.Close()
+
+	dropped := false
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		entry := JumpLogEntry{}
+
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			dropped = true
+
+			continue
+		}
+
+		if entry.Time.Before(cutoff) {
+			dropped = true
+
+			continue
+		}
+
+		l.entries[entry.Character] = append(l.entries[entry.Character], entry)
+	}
+
+	for _, entries := range l.entries {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Time.Before(entries[j].Time)
+		})
+	}
+
+	return dropped, scanner.Err()
+}
+
+// rewrite replaces the file at path with the entries that were kept.
+func (l *JumpLog) rewrite(path string) error {
+	var buf bytes.Buffer
+
+	for _, entry := range l.Entries("", time.Time{}) {
+		line, err := json.Marshal(entry)
+
+		if err != nil {
+			return err
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	return WritePrivateFile(path, buf.Bytes())
+}
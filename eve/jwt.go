@@ -0,0 +1,267 @@
+package eve
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dvsekhvalnov/jose2go/base64url"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	jwksTTL          = 24 * time.Hour
+	jwksRefetchDelay = time.Minute
+)
+
+var (
+	ErrTokenMalformed = errors.New("the access token is malformed")
+	ErrTokenSignature = errors.New("the access token signature is invalid")
+	ErrTokenIssuer    = errors.New("the access token was not issued by the EVE SSO")
+	ErrTokenAudience  = errors.New("the access token was not issued for this application")
+	ErrTokenExpired   = errors.New("the access token has expired")
+	ErrUnknownKey     = errors.New("the access token was signed with an unknown key")
+)
+
+type JWKS struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// jwksCache holds the SSO signing keys. An unknown kid triggers a refetch so
+// key rotation is picked up, at most once per jwksRefetchDelay.
+type jwksCache struct {
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func (c *jwksCache) key(kid string, fetch func() (*JWKS, error)) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stale := time.Since(c.fetchedAt) > jwksTTL
+
+	if key, ok := c.keys[kid]; ok && !stale {
+		return key, nil
+	}
+
+	if !stale && time.Since(c.fetchedAt) < jwksRefetchDelay {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+
+	jwks, err := fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := parseJWKS(jwks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+
+	key, ok := c.keys[kid]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+
+	return key, nil
+}
+
+// GetCharacter validates an SSO access token and returns the character it
+// was issued for.
+func (c *Client) GetCharacter(jwtString string) (AccessTokenJWT, error) {
+	claims := jwt.MapClaims{}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+
+	_, err := parser.ParseWithClaims(jwtString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, ok := t.Header["kid"].(string)
+
+		if !ok {
+			return nil, fmt.Errorf("%w: missing kid header", ErrTokenMalformed)
+		}
+
+		return c.keys.key(kid, c.fetchJWKS)
+	})
+
+	if err != nil {
+		return AccessTokenJWT{}, tokenError(err)
+	}
+
+	err = c.validateClaims(claims)
+
+	if err != nil {
+		return AccessTokenJWT{}, err
+	}
+
+	return characterFromClaims(claims)
+}
+
+func (c *Client) validateClaims(claims jwt.MapClaims) error {
+	issuer, err := claims.GetIssuer()
+
+	if err != nil || !contains(c.ssoIssuers(), issuer) {
+		return fmt.Errorf("%w: %q", ErrTokenIssuer, issuer)
+	}
+
+	audience, err := claims.GetAudience()
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTokenMalformed, err)
+	}
+
+	if !contains(audience, "EVE Online") || !contains(audience, c.ClientID) {
+		return fmt.Errorf("%w: %v", ErrTokenAudience, audience)
+	}
+
+	return nil
+}
+
+func characterFromClaims(claims jwt.MapClaims) (AccessTokenJWT, error) {
+	sub, err := claims.GetSubject()
+
+	if err != nil || !strings.HasPrefix(sub, "CHARACTER:EVE:") {
+		return AccessTokenJWT{}, fmt.Errorf("%w: invalid subject %q", ErrTokenMalformed, sub)
+	}
+
+	characterID, err := strconv.ParseInt(strings.TrimPrefix(sub, "CHARACTER:EVE:"), 10, 64)
+
+	if err != nil {
+		return AccessTokenJWT{}, fmt.Errorf("%w: invalid subject %q", ErrTokenMalformed, sub)
+	}
+
+	name, ok := claims["name"].(string)
+
+	if !ok {
+		return AccessTokenJWT{}, fmt.Errorf("%w: missing name claim", ErrTokenMalformed)
+	}
+
+	issuer, _ := claims.GetIssuer()
+	owner, _ := claims["owner"].(string)
+
+	character := AccessTokenJWT{
+		Name:        name,
+		Sub:         sub,
+		Iss:         issuer,
+		CharacterID: characterID,
+		Owner:       owner,
+		Scopes:      claimScopes(claims),
+	}
+
+	exp, err := claims.GetExpirationTime()
+
+	if err == nil && exp != nil {
+		character.ExpiresAt = exp.Time
+	}
+
+	return character, nil
+}
+
+// claimScopes reads the scp claim, which the SSO encodes as a string for a
+// single scope and as an array otherwise.
+func claimScopes(claims jwt.MapClaims) []string {
+	switch scp := claims["scp"].(type) {
+	case string:
+		return strings.Fields(scp)
+	case []interface{}:
+		var scopes []string
+
+		for _, scope := range scp {
+			if s, ok := scope.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+
+		return UnionScopes(scopes)
+	}
+
+	return nil
+}
+
+func tokenError(err error) error {
+	switch {
+	case errors.Is(err, ErrTokenMalformed), errors.Is(err, ErrUnknownKey):
+		return err
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return fmt.Errorf("%w: %v", ErrTokenSignature, err)
+	}
+
+	return fmt.Errorf("%w: %v", ErrTokenMalformed, err)
+}
+
+func (c *Client) fetchJWKS() (*JWKS, error) {
+	res, err := c.get(c.ssoRoute("/oauth/jwks"), PriorityHigh)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	var jwks JWKS
+
+	err = ProcessBody(res.Body, &jwks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &jwks, nil
+}
+
+func parseJWKS(jwks *JWKS) (map[string]*rsa.PublicKey, error) {
+	keys := make(map[string]*rsa.PublicKey)
+
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" || key.Use != "sig" {
+			continue
+		}
+
+		modulus, err := base64url.Decode(key.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		exponent, err := base64url.Decode(key.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(exponent).Int64()),
+		}
+	}
+
+	return keys, nil
+}
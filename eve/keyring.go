@@ -0,0 +1,23 @@
+package eve
+
+import "errors"
+
+var ErrKeyringUnavailable = errors.New("no keyring is available")
+
+var ErrKeyringNotFound = errors.New("the secret is not in the keyring")
+
+var ErrKeyringDismissed = errors.New("the keyring prompt was dismissed")
+
+// Keyring keeps secrets in the credential store of the operating system: the
+// Secret Service on Linux, the login keychain on macOS and the Credential
+// Manager on Windows.
+type Keyring interface {
+	Get(service string, account string) (string, error)
+	Set(service string, account string, secret string) error
+}
+
+// SystemKeyring returns the keyring of the operating system. Its methods
+// return ErrKeyringUnavailable where there is none.
+func SystemKeyring() Keyring {
+	return systemKeyring{}
+}
@@ -0,0 +1,53 @@
+package eve
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const securityTool = "/usr/bin/security"
+
+// errSecItemNotFound is the exit status of security when there is no such
+// item.
+const errSecItemNotFound = 44
+
+// systemKeyring keeps secrets in the login keychain through the security
+// tool.
+type systemKeyring struct{}
+
+func (systemKeyring) Get(service string, account string) (string, error) {
+	out, err := exec.Command(securityTool, "find-generic-password", "-s", service, "-a", account, "-w").Output()
+
+	var exitErr *exec.ExitError
+
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+		return "", ErrKeyringNotFound
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// Set passes the secret on stdin so it does not show up in the process list.
+func (systemKeyring) Set(service string, account string, secret string) error {
+	if strings.ContainsAny(service+account+secret, "\"\\\n") {
+		return errors.New("the secret cannot be passed to the keychain")
+	}
+
+	cmd := exec.Command(securityTool, "-i")
+
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -w \"%s\"\n", service, account, secret))
+
+	out, err := cmd.CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrKeyringUnavailable, err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
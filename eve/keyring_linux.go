@@ -0,0 +1,224 @@
+package eve
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretsName       = "org.freedesktop.secrets"
+	secretsPath       = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretsCollection = dbus.ObjectPath("/org/freedesktop/secrets/aliases/default")
+	secretsNoPrompt   = dbus.ObjectPath("/")
+
+	// keyringPromptTimeout is how long the user has to answer an unlock
+	// prompt before it counts as dismissed.
+	keyringPromptTimeout = 2 * time.Minute
+)
+
+// systemKeyring talks to the Secret Service (GNOME Keyring, KWallet) over the
+// session bus.
+type systemKeyring struct{}
+
+type secretServiceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+func (systemKeyring) Get(service string, account string) (string, error) {
+	conn, err := dbus.SessionBus()
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	var unlocked, locked []dbus.ObjectPath
+
+	err = conn.Object(secretsName, secretsPath).
+		Call("org.freedesktop.Secret.Service.SearchItems", 0, secretAttributes(service, account)).
+		Store(&unlocked, &locked)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	items := append(unlocked, locked...)
+
+	if len(items) == 0 {
+		return "", ErrKeyringNotFound
+	}
+
+	item := items[0]
+
+	if len(unlocked) == 0 {
+		err = unlockSecrets(conn, item)
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	session, err := openSecretSession(conn)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Object(secretsName, session).Call("org.freedesktop.Secret.Session.Close", 0)
+
+	secret := secretServiceSecret{}
+
+	err = conn.Object(secretsName, item).Call("org.freedesktop.Secret.Item.GetSecret", 0, session).Store(&secret)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret.Value), nil
+}
+
+func (systemKeyring) Set(service string, account string, secret string) error {
+	conn, err := dbus.SessionBus()
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	err = unlockSecrets(conn, secretsCollection)
+
+	if err != nil {
+		return err
+	}
+
+	session, err := openSecretSession(conn)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Object(secretsName, session).Call("org.freedesktop.Secret.Session.Close", 0)
+
+	properties := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant(service + " " + account),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(secretAttributes(service, account)),
+	}
+
+	value := secretServiceSecret{
+		Session:     session,
+		Parameters:  []byte{},
+		Value:       []byte(secret),
+		ContentType: "text/plain",
+	}
+
+	var item, prompt dbus.ObjectPath
+
+	err = conn.Object(secretsName, secretsCollection).
+		Call("org.freedesktop.Secret.Collection.CreateItem", 0, properties, value, true).
+		Store(&item, &prompt)
+
+	if err != nil {
+		return err
+	}
+
+	return runSecretPrompt(conn, prompt)
+}
+
+func secretAttributes(service string, account string) map[string]string {
+	return map[string]string{
+		"service":  service,
+		"username": account,
+	}
+}
+
+// openSecretSession opens a session that passes secrets unencrypted, which
+// is fine on the session bus of the user.
+func openSecretSession(conn *dbus.Conn) (dbus.ObjectPath, error) {
+	var output dbus.Variant
+	var session dbus.ObjectPath
+
+	err := conn.Object(secretsName, secretsPath).
+		Call("org.freedesktop.Secret.Service.OpenSession", 0, "plain", dbus.MakeVariant("")).
+		Store(&output, &session)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	return session, nil
+}
+
+// unlockSecrets unlocks an item or collection, which may ask the user for the
+// keyring password.
+func unlockSecrets(conn *dbus.Conn, path dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+
+	err := conn.Object(secretsName, secretsPath).
+		Call("org.freedesktop.Secret.Service.Unlock", 0, []dbus.ObjectPath{path}).
+		Store(&unlocked, &prompt)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	return runSecretPrompt(conn, prompt)
+}
+
+func runSecretPrompt(conn *dbus.Conn, prompt dbus.ObjectPath) error {
+	if prompt == secretsNoPrompt || prompt == "" {
+		return nil
+	}
+
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface("org.freedesktop.Secret.Prompt"),
+		dbus.WithMatchMember("Completed"),
+	}
+
+	err := conn.AddMatchSignal(match...)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.RemoveMatchSignal(match...)
+
+	signals := make(chan *dbus.Signal, 1)
+
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	err = conn.Object(secretsName, prompt).Call("org.freedesktop.Secret.Prompt.Prompt", 0, "").Err
+
+	if err != nil {
+		return err
+	}
+
+	timeout := time.NewTimer(keyringPromptTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case signal := <-signals:
+			if signal.Path != prompt || signal.Name != "org.freedesktop.Secret.Prompt.Completed" {
+				continue
+			}
+
+			if len(signal.Body) > 0 {
+				if dismissed, ok := signal.Body[0].(bool); ok && dismissed {
+					return ErrKeyringDismissed
+				}
+			}
+
+			return nil
+		case <-timeout.C:
+			conn.Object(secretsName, prompt).Call("org.freedesktop.Secret.Prompt.Dismiss", 0)
+
+			return ErrKeyringDismissed
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package eve
+
+type systemKeyring struct{}
+
+func (systemKeyring) Get(service string, account string) (string, error) {
+	return "", ErrKeyringUnavailable
+}
+
+func (systemKeyring) Set(service string, account string, secret string) error {
+	return ErrKeyringUnavailable
+}
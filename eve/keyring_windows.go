@@ -0,0 +1,101 @@
+package eve
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// credential is the CREDENTIALW struct of wincred.h.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// systemKeyring keeps secrets as generic credentials of the Windows
+// Credential Manager.
+type systemKeyring struct{}
+
+func (systemKeyring) Get(service string, account string) (string, error) {
+	target, err := syscall.UTF16PtrFromString(service + ":" + account)
+
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+
+	ok, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+
+	if ok == 0 {
+		if errors.Is(err, errorNotFound) {
+			return "", ErrKeyringNotFound
+		}
+
+		return "", fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func (systemKeyring) Set(service string, account string, secret string) error {
+	target, err := syscall.UTF16PtrFromString(service + ":" + account)
+
+	if err != nil {
+		return err
+	}
+
+	user, err := syscall.UTF16PtrFromString(account)
+
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(secret)
+
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	ok, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+
+	if ok == 0 {
+		return fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,328 @@
+package eve
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dvsekhvalnov/jose2go/base64url"
+)
+
+const (
+	DefaultCallbackPort = 3003
+	DefaultLoginTimeout = 5 * time.Minute
+)
+
+var (
+	ErrPortInUse     = errors.New("the SSO callback port is already in use")
+	ErrStateMismatch = errors.New("the SSO callback state does not match the login request")
+	ErrLoginDenied   = errors.New("the SSO login was not authorized")
+)
+
+// LoginFlow runs the SSO authorization code flow with PKCE against a local
+// callback server. Only one login runs at a time; starting another while one
+// is in flight adds its scopes to the pending request, reopens the login page
+// and waits for the same result.
+type LoginFlow struct {
+	client *Client
+
+	// Port is the callback port. Zero picks a free port, which then has to
+	// match the callback URL registered for the application.
+	Port    int
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	attempt *loginAttempt
+}
+
+type loginAttempt struct {
+	loginURL string
+	done     chan struct{}
+	once     sync.Once
+	session  Session
+	err      error
+
+	mu     sync.Mutex
+	scopes []string
+}
+
+// addScopes merges scopes into the ones the login page asks for.
+func (a *loginAttempt) addScopes(scopes []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, scope := range scopes {
+		if !slices.Contains(a.scopes, scope) {
+			a.scopes = append(a.scopes, scope)
+		}
+	}
+}
+
+func (a *loginAttempt) scope() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return strings.Join(a.scopes, " ")
+}
+
+func (a *loginAttempt) finish(session Session, err error) {
+	a.once.Do(func() {
+		a.session = session
+		a.err = err
+
+		close(a.done)
+	})
+}
+
+func NewLoginFlow(client *Client, port int) *LoginFlow {
+	return &LoginFlow{
+		client:  client,
+		Port:    port,
+		Timeout: DefaultLoginTimeout,
+	}
+}
+
+// Login requests the given scopes, calls open with the URL the user has to
+// visit and blocks until the callback arrives, ctx is done, the flow times
+// out or Cancel is called.
+func (f *LoginFlow) Login(ctx context.Context, scopes []string, open func(loginURL string) error) (Session, error) {
+	attempt, err := f.start(scopes)
+
+	if err != nil {
+		return Session{}, err
+	}
+
+	err = open(attempt.loginURL)
+
+	if err != nil {
+		return Session{}, err
+	}
+
+	select {
+	case <-attempt.done:
+		return attempt.session, attempt.err
+	case <-ctx.Done():
+		return Session{}, ctx.Err()
+	}
+}
+
+// Cancel aborts the login in flight, if any.
+func (f *LoginFlow) Cancel() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.attempt != nil {
+		f.attempt.finish(Session{}, context.Canceled)
+	}
+}
+
+func (f *LoginFlow) start(scopes []string) (*loginAttempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.attempt != nil {
+		f.attempt.addScopes(scopes)
+
+		return f.attempt, nil
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(f.Port))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPortInUse, err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	verifier, err := generateState()
+
+	if err != nil {
+		listener.Close()
+
+		return nil, err
+	}
+
+	state, err := generateState()
+
+	if err != nil {
+		listener.Close()
+
+		return nil, err
+	}
+
+	timeout := f.Timeout
+
+	if timeout == 0 {
+		timeout = DefaultLoginTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	attempt := &loginAttempt{
+		loginURL: fmt.Sprintf("http://localhost:%d/login", port),
+		done:     make(chan struct{}),
+	}
+
+	attempt.addScopes(scopes)
+
+	redirectURI := fmt.Sprintf("http://localhost:%d/callback", port)
+
+	server := &http.Server{
+		Handler: f.client.loginHandler(attempt, redirectURI, state, verifier),
+	}
+
+	go func() {
+		err := server.Serve(listener)
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			attempt.finish(Session{}, err)
+		}
+	}()
+
+	go func() {
+		select {
+		case <-attempt.done:
+		case <-ctx.Done():
+			attempt.finish(Session{}, fmt.Errorf("the SSO login timed out: %w", ctx.Err()))
+		}
+
+		cancel()
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+
+		server.Shutdown(shutdownCtx)
+
+		f.mu.Lock()
+
+		if f.attempt == attempt {
+			f.attempt = nil
+		}
+
+		f.mu.Unlock()
+	}()
+
+	f.attempt = attempt
+
+	return attempt, nil
+}
+
+func (c *Client) loginHandler(attempt *loginAttempt, redirectURI string, state string, verifier string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		hash := sha256.New()
+
+		hash.Write([]byte(verifier))
+
+		data := url.Values{}
+
+		data.Add("response_type", "code")
+		data.Add("redirect_uri", redirectURI)
+		data.Add("client_id", c.ClientID)
+		data.Add("scope", attempt.scope())
+		data.Add("code_challenge", base64url.Encode(hash.Sum(nil)))
+		data.Add("code_challenge_method", "S256")
+		data.Add("state", state)
+
+		http.Redirect(w, r, c.ssoRoute("/v2/oauth/authorize")+"?"+data.Encode(), http.StatusTemporaryRedirect) // redirect user to oauth endpoint
+	})
+
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		// A stale or forged callback must not end the login the user is
+		// still going through.
+		if query.Get("state") != state {
+			http.Error(w, ErrStateMismatch.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		if query.Get("error") != "" {
+			http.Error(w, ErrLoginDenied.Error(), http.StatusUnauthorized)
+
+			attempt.finish(Session{}, fmt.Errorf("%w: %s", ErrLoginDenied, query.Get("error")))
+
+			return
+		}
+
+		session, err := c.exchangeCode(query.Get("code"), verifier)
+
+		if err != nil {
+			fmt.Println(err)
+
+			http.Error(w, "there was an error authenticating", http.StatusInternalServerError)
+
+			attempt.finish(Session{}, err)
+
+			return
+		}
+
+		w.WriteHeader(200)
+		w.Write([]byte("You were authenticated."))
+
+		attempt.finish(session, nil)
+	})
+
+	return mux
+}
+
+func (c *Client) exchangeCode(code string, verifier string) (Session, error) {
+	tokenData := url.Values{}
+
+	tokenData.Add("grant_type", "authorization_code")
+	tokenData.Add("code", code)
+	tokenData.Add("client_id", c.ClientID)
+	tokenData.Add("code_verifier", verifier)
+
+	res, err := c.postForm(c.ssoRoute("/v2/oauth/token"), tokenData)
+
+	if err != nil {
+		return Session{}, err
+	}
+
+	defer res.Body.Close()
+
+	esiAuthResponse := ESIAuthResponse{}
+
+	err = ProcessBody(res.Body, &esiAuthResponse)
+
+	if err != nil {
+		return Session{}, err
+	}
+
+	if esiAuthResponse.AccessToken == "" {
+		return Session{}, errors.New("there was an error authenticating: " + res.Status)
+	}
+
+	character, err := c.GetCharacter(esiAuthResponse.AccessToken)
+
+	if err != nil {
+		return Session{}, err
+	}
+
+	return Session{
+		Character:    character,
+		AccessToken:  esiAuthResponse.AccessToken,
+		RefreshToken: esiAuthResponse.RefreshToken,
+		ExpiresAt:    expiresAt(esiAuthResponse),
+		Scopes:       character.Scopes,
+	}, nil
+}
+
+func generateState() (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	return base64url.Encode(bytes), err
+}
@@ -0,0 +1,164 @@
+package eve
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type CacheStats struct {
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Evictions uint64  `json:"evictions"`
+	Entries   int     `json:"entries"`
+	Bytes     int64   `json:"bytes"`
+	HitRatio  float64 `json:"hit_ratio"`
+}
+
+// TTLCache is a concurrency-safe LRU cache whose entries expire after a TTL
+// and whose estimated size is kept under a memory budget. A zero TTL never
+// expires entries; a zero budget never evicts them.
+type TTLCache[K comparable, V any] struct {
+	ttl      time.Duration
+	maxBytes int64
+	sizeOf   func(V) int64
+
+	mu    sync.Mutex
+	items map[K]*list.Element
+	order *list.List
+	bytes int64
+	stats CacheStats
+}
+
+type ttlCacheItem[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+	size    int64
+}
+
+func NewTTLCache[K comparable, V any](ttl time.Duration, maxBytes int64, sizeOf func(V) int64) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		sizeOf:   sizeOf,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+
+	if !ok {
+		c.stats.Misses++
+
+		var zero V
+
+		return zero, false
+	}
+
+	item := element.Value.(*ttlCacheItem[K, V])
+
+	if !item.expires.IsZero() && time.Now().After(item.expires) {
+		c.remove(element)
+
+		c.stats.Misses++
+
+		var zero V
+
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+
+	c.stats.Hits++
+
+	return item.value, true
+}
+
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	var expires time.Time
+
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+
+	c.SetUntil(key, value, expires)
+}
+
+// SetUntil stores value with an explicit expiry, e.g. when it is restored
+// from disk. A zero expiry never expires.
+func (c *TTLCache[K, V]) SetUntil(key K, value V, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+
+	item := &ttlCacheItem[K, V]{
+		key:     key,
+		value:   value,
+		expires: expires,
+		size:    c.sizeOf(value),
+	}
+
+	c.items[key] = c.order.PushFront(item)
+	c.bytes += item.size
+
+	for c.maxBytes > 0 && c.bytes > c.maxBytes && c.order.Len() > 1 {
+		c.remove(c.order.Back())
+
+		c.stats.Evictions++
+	}
+}
+
+// TTL is the lifetime given to entries stored with Set.
+func (c *TTLCache[K, V]) TTL() time.Duration {
+	return c.ttl
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *TTLCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.items)
+}
+
+func (c *TTLCache[K, V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.items)
+	stats.Bytes = c.bytes
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+func (c *TTLCache[K, V]) remove(element *list.Element) {
+	item := element.Value.(*ttlCacheItem[K, V])
+
+	c.order.Remove(element)
+
+	delete(c.items, item.key)
+
+	c.bytes -= item.size
+}
@@ -0,0 +1,100 @@
+package eve
+
+import (
+	"testing"
+	"time"
+)
+
+func byteSize(value string) int64 {
+	return int64(len(value))
+}
+
+func TestTTLCacheExpires(t *testing.T) {
+	cache := NewTTLCache[int, string](time.Hour, 0, byteSize)
+
+	cache.Set(1, "fresh")
+	cache.SetUntil(2, "stale", time.Now().Add(-time.Second))
+	cache.SetUntil(3, "forever", time.Time{})
+
+	if value, ok := cache.Get(1); !ok || value != "fresh" {
+		t.Errorf("Get(1) = %q, %v, want the entry set with the TTL", value, ok)
+	}
+
+	if _, ok := cache.Get(2); ok {
+		t.Error("Get(2) returned an expired entry")
+	}
+
+	if _, ok := cache.Get(3); !ok {
+		t.Error("Get(3) lost an entry without expiry")
+	}
+
+	stats := cache.Stats()
+
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 2 || stats.Bytes != int64(len("fresh")+len("forever")) {
+		t.Errorf("Stats() = %+v, the expired entry should be gone", stats)
+	}
+}
+
+func TestTTLCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewTTLCache[int, string](0, 10, byteSize)
+
+	cache.Set(1, "aaaa")
+	cache.Set(2, "bbbb")
+
+	// reading 1 makes 2 the least recently used entry
+	cache.Get(1)
+
+	cache.Set(3, "cccc")
+
+	if _, ok := cache.Get(2); ok {
+		t.Error("the least recently used entry was not evicted")
+	}
+
+	for _, key := range []int{1, 3} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Get(%d) lost an entry within the budget", key)
+		}
+	}
+
+	stats := cache.Stats()
+
+	if stats.Evictions != 1 || stats.Bytes != 8 {
+		t.Errorf("Stats() = %+v, want 1 eviction and 8 bytes", stats)
+	}
+}
+
+func TestTTLCacheKeepsOversizedEntry(t *testing.T) {
+	cache := NewTTLCache[int, string](0, 4, byteSize)
+
+	cache.Set(1, "aa")
+	cache.Set(2, "larger than the budget")
+
+	if _, ok := cache.Get(2); !ok {
+		t.Error("an entry larger than the budget was dropped right away")
+	}
+
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want only the oversized entry", cache.Len())
+	}
+}
+
+func TestTTLCacheReplacesEntries(t *testing.T) {
+	cache := NewTTLCache[int, string](0, 0, byteSize)
+
+	cache.Set(1, "short")
+	cache.Set(1, "much longer")
+
+	if value, _ := cache.Get(1); value != "much longer" {
+		t.Errorf("Get(1) = %q, want the replacement", value)
+	}
+
+	if stats := cache.Stats(); stats.Entries != 1 || stats.Bytes != int64(len("much longer")) {
+		t.Errorf("Stats() = %+v, the replaced entry is still counted", stats)
+	}
+
+	cache.Delete(1)
+
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Stats() after Delete = %+v", stats)
+	}
+}
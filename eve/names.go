@@ -0,0 +1,140 @@
+package eve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const universeNamesChunk = 1000
+
+type UniverseName struct {
+	Id       int64  `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+var errNamesRejected = errors.New("the IDs were rejected by /universe/names")
+
+// ResolveNames returns the names of the given character, corporation and
+// alliance IDs, which only need their Id and Category set. IDs already in the
+// cache of their category are not requested again; the rest
+// are resolved in batches through /universe/names and stored in the cache.
+// When ESI rejects a batch because one of its IDs is invalid, the IDs of that
+// batch are resolved one by one and the invalid ones are left out.
+func (c *Client) ResolveNames(ctx context.Context, ids []UniverseName, cache *Cache) (map[int64]string, error) {
+	names := make(map[int64]string)
+
+	var unresolved []int64
+
+	seen := make(map[int64]bool)
+
+	for _, entity := range ids {
+		id := entity.Id
+
+		if id == 0 || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+
+		if name, ok := cache.Name(entity.Category, id); ok {
+			names[id] = name
+
+			continue
+		}
+
+		unresolved = append(unresolved, id)
+	}
+
+	for i := 0; i < len(unresolved); i += universeNamesChunk {
+		end := min(i+universeNamesChunk, len(unresolved))
+
+		resolved, err := c.postUniverseNames(ctx, unresolved[i:end])
+
+		if errors.Is(err, errNamesRejected) {
+			resolved, err = c.resolveNamesSeparately(ctx, unresolved[i:end])
+		}
+
+		if err != nil {
+			return names, err
+		}
+
+		for _, name := range resolved {
+			names[name.Id] = name.Name
+
+			cache.SetName(name)
+		}
+	}
+
+	return names, nil
+}
+
+func (c *Client) resolveNamesSeparately(ctx context.Context, ids []int64) ([]UniverseName, error) {
+	var resolved []UniverseName
+
+	for _, id := range ids {
+		names, err := c.postUniverseNames(ctx, []int64{id})
+
+		if errors.Is(err, errNamesRejected) {
+			fmt.Println("could not resolve name of", id)
+
+			continue
+		}
+
+		if err != nil {
+			return resolved, err
+		}
+
+		resolved = append(resolved, names...)
+	}
+
+	return resolved, nil
+}
+
+func (c *Client) postUniverseNames(ctx context.Context, ids []int64) ([]UniverseName, error) {
+	body, err := json.Marshal(ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.esiRoute("/universe/names/", nil), bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.do(req, PriorityLow)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusBadRequest {
+		res.Body.Close()
+
+		return nil, errNamesRejected
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+
+		return nil, errors.New(res.Status)
+	}
+
+	var names []UniverseName
+
+	err = ProcessBody(res.Body, &names)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
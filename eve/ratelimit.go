@@ -0,0 +1,189 @@
+package eve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Priority int
+
+const (
+	// PriorityLow requests, like name lookups and killmail bodies, are
+	// dropped first when the error budget runs low.
+	PriorityLow Priority = iota
+	PriorityNormal
+	// PriorityHigh requests, like the location of the current character,
+	// only wait out an explicit 420 or 429.
+	PriorityHigh
+)
+
+const (
+	esiErrorLimit       = 100
+	lowPriorityReserve  = 20
+	pausePriorityBudget = 5
+
+	// errorBudgetWindow is how long the error budget is assumed to last when
+	// a host did not send X-ESI-Error-Limit-Reset.
+	errorBudgetWindow = time.Minute
+)
+
+var (
+	ErrRequestShed = errors.New("the request was dropped to preserve the ESI error budget")
+	ErrRateLimited = errors.New("the request was rate limited")
+)
+
+type ErrorBudget struct {
+	Remain       int       `json:"remain"`
+	ResetAt      time.Time `json:"reset_at"`
+	BlockedUntil time.Time `json:"blocked_until"`
+	Shed         int       `json:"shed"`
+}
+
+// ErrorLimiter tracks the error budget a host reports through the
+// X-ESI-Error-Limit headers and the Retry-After of 420 and 429 responses.
+type ErrorLimiter struct {
+	mu           sync.Mutex
+	remain       int
+	resetAt      time.Time
+	blockedUntil time.Time
+	shed         int
+}
+
+func NewErrorLimiter() *ErrorLimiter {
+	return &ErrorLimiter{
+		remain: esiErrorLimit,
+	}
+}
+
+// Acquire blocks until a request of the given priority may be sent, or
+// returns ErrRequestShed if it should not be sent at all.
+func (l *ErrorLimiter) Acquire(ctx context.Context, priority Priority) error {
+	for {
+		wait, err := l.delay(priority)
+
+		if err != nil || wait <= 0 {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *ErrorLimiter) delay(priority Priority) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if !l.resetAt.IsZero() && now.After(l.resetAt) {
+		l.remain = esiErrorLimit
+		l.resetAt = time.Time{}
+	}
+
+	if now.Before(l.blockedUntil) {
+		if priority == PriorityLow {
+			l.shed++
+
+			return 0, ErrRequestShed
+		}
+
+		return l.blockedUntil.Sub(now), nil
+	}
+
+	if l.remain <= pausePriorityBudget {
+		if l.resetAt.IsZero() {
+			l.resetAt = now.Add(errorBudgetWindow)
+		}
+
+		switch priority {
+		case PriorityLow:
+			l.shed++
+
+			return 0, ErrRequestShed
+		case PriorityNormal:
+			return l.resetAt.Sub(now), nil
+		}
+	}
+
+	if l.remain <= lowPriorityReserve && priority == PriorityLow {
+		l.shed++
+
+		return 0, ErrRequestShed
+	}
+
+	return 0, nil
+}
+
+// Observe records the error budget and rate limit state of a response.
+func (l *ErrorLimiter) Observe(res *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if remain, err := strconv.Atoi(res.Header.Get("X-ESI-Error-Limit-Remain")); err == nil {
+		l.remain = remain
+	}
+
+	if reset, err := strconv.Atoi(res.Header.Get("X-ESI-Error-Limit-Reset")); err == nil {
+		l.resetAt = now.Add(time.Duration(reset) * time.Second)
+	}
+
+	if res.StatusCode != 420 && res.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+
+	blockedUntil, ok := retryAfter(res.Header.Get("Retry-After"), now)
+
+	if !ok {
+		blockedUntil = l.resetAt
+
+		if blockedUntil.Before(now) {
+			blockedUntil = now.Add(time.Minute)
+		}
+	}
+
+	if blockedUntil.After(l.blockedUntil) {
+		l.blockedUntil = blockedUntil
+	}
+}
+
+func (l *ErrorLimiter) Budget() ErrorBudget {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return ErrorBudget{
+		Remain:       l.remain,
+		ResetAt:      l.resetAt,
+		BlockedUntil: l.blockedUntil,
+		Shed:         l.shed,
+	}
+}
+
+func retryAfter(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return date, true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,228 @@
+package eve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	refreshLead       = 2 * time.Minute
+	refreshInterval   = 30 * time.Second
+	refreshMinBackoff = 15 * time.Second
+	refreshMaxBackoff = 10 * time.Minute
+)
+
+// Refresher keeps the access token of every registered character fresh by
+// refreshing it shortly before it expires. Characters whose refresh token was
+// revoked are reported instead of retried until they log in again.
+type Refresher struct {
+	client   *Client
+	sessions *SessionManager
+
+	// OnRefresh is called with every session that got new tokens from the
+	// SSO, even when its new access token did not validate.
+	OnRefresh func(Session)
+	// OnRevoked is called with the name of a character whose refresh token
+	// the SSO rejected.
+	OnRevoked func(string)
+
+	mu       sync.Mutex
+	revoked  map[string]string
+	failures map[string]int
+	retryAt  map[string]time.Time
+
+	// refreshing serializes the refreshes of each character; the SSO rotates
+	// refresh tokens, so a second concurrent refresh would be rejected
+	refreshing map[string]*sync.Mutex
+}
+
+func NewRefresher(client *Client, sessions *SessionManager) *Refresher {
+	return &Refresher{
+		client:   client,
+		sessions: sessions,
+		revoked:  make(map[string]string),
+		failures: make(map[string]int),
+		retryAt:  make(map[string]time.Time),
+
+		refreshing: make(map[string]*sync.Mutex),
+	}
+}
+
+// Run refreshes due tokens until ctx is cancelled.
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		r.RefreshDue()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshDue refreshes every character whose token expires within the
+// refresh lead time and that is not waiting on a backoff.
+func (r *Refresher) RefreshDue() {
+	now := time.Now()
+
+	for _, name := range r.sessions.Characters() {
+		session, ok := r.sessions.Get(name)
+
+		if !ok || session.ExpiresAt.Sub(now) > refreshLead {
+			continue
+		}
+
+		if r.IsRevoked(name) {
+			continue
+		}
+
+		r.mu.Lock()
+		retryAt := r.retryAt[name]
+		r.mu.Unlock()
+
+		if now.Before(retryAt) {
+			continue
+		}
+
+		r.Refresh(name)
+	}
+}
+
+// Refresh refreshes a single character immediately. Callers that wait on a
+// refresh of the same character already in flight get its session instead of
+// refreshing again.
+func (r *Refresher) Refresh(name string) (Session, error) {
+	before, _ := r.sessions.Get(name)
+
+	lock := r.lock(name)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if after, ok := r.sessions.Get(name); ok && after.RefreshToken != before.RefreshToken {
+		return after, nil
+	}
+
+	session, err := r.client.RefreshToken(r.sessions, name)
+
+	if errors.Is(err, ErrUnknownCharacter) {
+		return session, err
+	}
+
+	r.mu.Lock()
+
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenRevoked) {
+			r.revoked[name] = session.RefreshToken
+		} else {
+			r.failures[name]++
+			r.retryAt[name] = time.Now().Add(backoff(r.failures[name]))
+		}
+
+		r.mu.Unlock()
+
+		fmt.Println(name, err)
+
+		// the exchange succeeded but the new access token did not validate,
+		// the rotated refresh token still has to outlive a restart
+		if session.RefreshToken != before.RefreshToken && r.OnRefresh != nil {
+			r.OnRefresh(session)
+		}
+
+		if errors.Is(err, ErrRefreshTokenRevoked) && r.OnRevoked != nil {
+			r.OnRevoked(name)
+		}
+
+		return session, err
+	}
+
+	delete(r.revoked, name)
+	delete(r.failures, name)
+	delete(r.retryAt, name)
+
+	r.mu.Unlock()
+
+	if r.OnRefresh != nil {
+		r.OnRefresh(session)
+	}
+
+	return session, nil
+}
+
+func (r *Refresher) lock(name string) *sync.Mutex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lock, ok := r.refreshing[name]
+
+	if !ok {
+		lock = &sync.Mutex{}
+
+		r.refreshing[name] = lock
+	}
+
+	return lock
+}
+
+// IsRevoked reports whether the character's stored refresh token was
+// rejected by the SSO. Logging in again stores a new refresh token and clears
+// the state.
+func (r *Refresher) IsRevoked(name string) bool {
+	session, ok := r.sessions.Get(name)
+
+	if !ok {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refreshToken, ok := r.revoked[name]
+
+	if ok && refreshToken != session.RefreshToken {
+		delete(r.revoked, name)
+
+		return false
+	}
+
+	return ok
+}
+
+// Forget drops the refresh state of a character that was removed.
+func (r *Refresher) Forget(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.revoked, name)
+	delete(r.failures, name)
+	delete(r.retryAt, name)
+}
+
+func (r *Refresher) Revoked() []string {
+	var names []string
+
+	for _, name := range r.sessions.Characters() {
+		if r.IsRevoked(name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func backoff(failures int) time.Duration {
+	delay := refreshMinBackoff
+
+	for i := 1; i < failures && delay < refreshMaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, refreshMaxBackoff)
+}
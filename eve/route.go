@@ -0,0 +1,257 @@
+package eve
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"eve-chaperone/eve/universe"
+)
+
+type RoutePreference string
+
+// The route preferences of the in-game autopilot.
+const (
+	RouteShortest   RoutePreference = "shortest"
+	RouteSafer      RoutePreference = "safer"
+	RouteLessSecure RoutePreference = "less-secure"
+)
+
+// avoidedSpaceCost outweighs any number of jumps through preferred space, so
+// the planner only enters the other kind of space when it has to.
+const avoidedSpaceCost = 50000
+
+var ErrUnknownSystem = errors.New("unknown solar system")
+
+var ErrNoRoute = errors.New("no stargate route")
+
+var ErrNoStaticMap = errors.New("the static map has no stargates; generate it with eve-chaperone sde import")
+
+type RouteOptions struct {
+	Preference RoutePreference `json:"preference"`
+	// Avoid lists systems by name or ID that the route may not pass through.
+	// They can still be the start or the end of a route.
+	Avoid []string `json:"avoid"`
+	// KillWindowMinutes is how far back route assessments count kills.
+	KillWindowMinutes int `json:"kill_window_minutes"`
+}
+
+type RouteHop struct {
+	SystemId        int64              `json:"system_id"`
+	Name            string             `json:"name"`
+	Security        float64            `json:"security"`
+	RoundedSecurity float64            `json:"rounded_security"`
+	SpaceType       universe.SpaceType `json:"space_type"`
+	Region          string             `json:"region"`
+}
+
+// StargateGraph plans routes over the stargates of the static map.
+type StargateGraph struct {
+	universe *universe.Universe
+	edges    map[int64][]int64
+}
+
+func NewStargateGraph(u *universe.Universe) *StargateGraph {
+	graph := &StargateGraph{
+		universe: u,
+		edges:    make(map[int64][]int64),
+	}
+
+	for _, system := range u.Systems() {
+		if neighbours := u.Neighbours(system.Id); len(neighbours) > 0 {
+			graph.edges[system.Id] = neighbours
+		}
+	}
+
+	if len(graph.edges) == 0 {
+		fmt.Println("route and jump planning are off:", ErrNoStaticMap)
+	}
+
+	return graph
+}
+
+// System looks a system up by name or ID.
+func (g *StargateGraph) System(nameOrId string) (universe.System, error) {
+	if id, err := strconv.ParseInt(strings.TrimSpace(nameOrId), 10, 64); err == nil {
+		if system, ok := g.universe.System(id); ok {
+			return system, nil
+		}
+	}
+
+	if system, ok := g.universe.SystemByName(nameOrId); ok {
+		return system, nil
+	}
+
+	if len(g.edges) == 0 {
+		return universe.System{}, ErrNoStaticMap
+	}
+
+	return universe.System{}, fmt.Errorf("%w: %s", ErrUnknownSystem, nameOrId)
+}
+
+// Route returns the systems from one system to another, both included,
+// following the given preference.
+func (g *StargateGraph) Route(from string, to string, options RouteOptions) ([]RouteHop, error) {
+	if len(g.edges) == 0 {
+		return nil, ErrNoStaticMap
+	}
+
+	start, err := g.System(from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := g.System(to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	avoid := make(map[int64]bool)
+
+	for _, name := range options.Avoid {
+		system, err := g.System(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		avoid[system.Id] = true
+	}
+
+	path, ok := g.shortestPath(start.Id, end.Id, avoid, g.costFunc(options.Preference))
+
+	if !ok {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoRoute, start.Name, end.Name)
+	}
+
+	hops := make([]RouteHop, 0, len(path))
+
+	for _, id := range path {
+		system, _ := g.universe.System(id)
+
+		hops = append(hops, routeHop(system))
+	}
+
+	return hops, nil
+}
+
+// costFunc returns the cost of jumping into a system.
+func (g *StargateGraph) costFunc(preference RoutePreference) func(id int64) int {
+	highsec := func(id int64) bool {
+		system, _ := g.universe.System(id)
+
+		return system.SpaceType == universe.Highsec
+	}
+
+	switch preference {
+	case RouteSafer:
+		return func(id int64) int {
+			if highsec(id) {
+				return 1
+			}
+
+			return avoidedSpaceCost
+		}
+	case RouteLessSecure:
+		return func(id int64) int {
+			if highsec(id) {
+				return avoidedSpaceCost
+			}
+
+			return 1
+		}
+	}
+
+	return func(id int64) int {
+		return 1
+	}
+}
+
+// shortestPath runs Dijkstra from start to end without entering avoided
+// systems other than end.
+func (g *StargateGraph) shortestPath(start int64, end int64, avoid map[int64]bool, cost func(id int64) int) ([]int64, bool) {
+	dist := map[int64]int{start: 0}
+	prev := make(map[int64]int64)
+
+	queue := &routeQueue{{system: start}}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(routeItem)
+
+		if item.system == end {
+			break
+		}
+
+		if item.cost > dist[item.system] {
+			continue
+		}
+
+		for _, next := range g.edges[item.system] {
+			if avoid[next] && next != end {
+				continue
+			}
+
+			nextCost := item.cost + cost(next)
+
+			if known, ok := dist[next]; ok && known <= nextCost {
+				continue
+			}
+
+			dist[next] = nextCost
+			prev[next] = item.system
+
+			heap.Push(queue, routeItem{system: next, cost: nextCost})
+		}
+	}
+
+	if _, ok := dist[end]; !ok {
+		return nil, false
+	}
+
+	path := []int64{end}
+
+	for id := end; id != start; {
+		id = prev[id]
+
+		path = append(path, id)
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, true
+}
+
+type routeItem struct {
+	system int64
+	cost   int
+}
+
+type routeQueue []routeItem
+
+func (q routeQueue) Len() int { return len(q) }
+
+func (q routeQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+
+	return q[i].system < q[j].system
+}
+
+func (q routeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *routeQueue) Push(x interface{}) { *q = append(*q, x.(routeItem)) }
+
+func (q *routeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return item
+}
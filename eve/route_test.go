@@ -0,0 +1,146 @@
+package eve
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"eve-chaperone/eve/data"
+	"eve-chaperone/eve/universe"
+)
+
+// loadTestUniverse loads a small map from fixture tables:
+//
+//	Alpha (0.9) - Bravo (0.8) - Charlie (0.9) - Golf (0.7)
+//	    \                                      /
+//	     `---------- Delta (0.3) -------------'
+//
+// Delta, Echo (-0.2) and Foxtrot (0.2) lie 4 light years apart on a line for
+// the jump planner; Island (0.5) has no stargates.
+func loadTestUniverse(t *testing.T) *universe.Universe {
+	t.Helper()
+
+	system := func(id int64, name string, security float64, x float64) data.System {
+		return data.System{Id: id, Name: name, ConstellationId: 20000001, RegionId: 10000001, Security: security, X: x * LightYear}
+	}
+
+	systems := []data.System{
+		system(30000001, "Alpha", 0.9, -20),
+		system(30000002, "Bravo", 0.8, -30),
+		system(30000003, "Charlie", 0.9, -40),
+		system(30000004, "Delta", 0.3, 0),
+		system(30000005, "Echo", -0.2, 4),
+		system(30000006, "Foxtrot", 0.2, 8),
+		system(30000007, "Golf", 0.7, -50),
+		system(30000008, "Island", 0.5, -60),
+	}
+
+	var stargates []data.Stargate
+
+	for _, pair := range [][2]int64{
+		{30000001, 30000002},
+		{30000002, 30000003},
+		{30000003, 30000007},
+		{30000001, 30000004},
+		{30000004, 30000007},
+	} {
+		stargates = append(stargates,
+			data.Stargate{Id: int64(len(stargates)) + 50000001, SystemId: pair[0], DestinationSystemId: pair[1]},
+			data.Stargate{Id: int64(len(stargates)) + 50000002, SystemId: pair[1], DestinationSystemId: pair[0]},
+		)
+	}
+
+	tables := map[string]interface{}{
+		data.RegionsFile:        []data.Region{{Id: 10000001, Name: "Test Region"}},
+		data.ConstellationsFile: []data.Constellation{{Id: 20000001, Name: "Test Constellation", RegionId: 10000001}},
+		data.SystemsFile:        systems,
+		data.StargatesFile:      stargates,
+	}
+
+	dir := t.TempDir()
+
+	for name, table := range tables {
+		b, err := json.Marshal(table)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filepath.Join(dir, name), b, 0644)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous := data.Dir
+	data.Dir = dir
+
+	t.Cleanup(func() { data.Dir = previous })
+
+	u, err := universe.Load()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+func routeNames(hops []RouteHop) []string {
+	names := make([]string, 0, len(hops))
+
+	for _, hop := range hops {
+		names = append(names, hop.Name)
+	}
+
+	return names
+}
+
+func TestStargateGraphRoute(t *testing.T) {
+	graph := NewStargateGraph(loadTestUniverse(t))
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		options RouteOptions
+		want    []string
+		wantErr error
+	}{
+		{name: "shortest", from: "Alpha", to: "Golf", want: []string{"Alpha", "Delta", "Golf"}},
+		{name: "safer", from: "Alpha", to: "Golf", options: RouteOptions{Preference: RouteSafer}, want: []string{"Alpha", "Bravo", "Charlie", "Golf"}},
+		{name: "avoid", from: "alpha", to: "30000007", options: RouteOptions{Avoid: []string{"Delta"}}, want: []string{"Alpha", "Bravo", "Charlie", "Golf"}},
+		{name: "avoided end", from: "Alpha", to: "Delta", options: RouteOptions{Avoid: []string{"Delta"}}, want: []string{"Alpha", "Delta"}},
+		{name: "same system", from: "Alpha", to: "Alpha", want: []string{"Alpha"}},
+		{name: "unknown system", from: "Alpha", to: "Nowhere", wantErr: ErrUnknownSystem},
+		{name: "unreachable", from: "Alpha", to: "Island", wantErr: ErrNoRoute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hops, err := graph.Route(test.from, test.to, test.options)
+
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Route() error = %v, want %v", err, test.wantErr)
+			}
+
+			if got := routeNames(hops); test.wantErr == nil && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Route() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStargateGraphWithoutMap(t *testing.T) {
+	graph := NewStargateGraph(&universe.Universe{})
+
+	_, err := graph.Route("Jita", "Amarr", RouteOptions{})
+
+	if !errors.Is(err, ErrNoStaticMap) {
+		t.Errorf("Route() error = %v, want %v", err, ErrNoStaticMap)
+	}
+}
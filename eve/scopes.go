@@ -0,0 +1,96 @@
+package eve
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	ScopeReadLocation = "esi-location.read_location.v1"
+	ScopeReadShipType = "esi-location.read_ship_type.v1"
+)
+
+var scopeRegistry = struct {
+	mu       sync.RWMutex
+	features map[string][]string
+}{
+	features: make(map[string][]string),
+}
+
+type CharacterScopes struct {
+	CharacterName string   `json:"name"`
+	Granted       []string `json:"granted"`
+	Missing       []string `json:"missing"`
+}
+
+func init() {
+	RegisterScopes("location", ScopeReadLocation, ScopeReadShipType)
+}
+
+// RegisterScopes declares the ESI scopes a feature needs. Registering the
+// same feature again replaces its scopes.
+func RegisterScopes(feature string, scopes ...string) {
+	scopeRegistry.mu.Lock()
+	defer scopeRegistry.mu.Unlock()
+
+	scopeRegistry.features[feature] = scopes
+}
+
+// RequiredScopes returns the sorted union of the scopes of every registered
+// feature.
+func RequiredScopes() []string {
+	scopeRegistry.mu.RLock()
+	defer scopeRegistry.mu.RUnlock()
+
+	var scopes []string
+
+	for _, featureScopes := range scopeRegistry.features {
+		scopes = append(scopes, featureScopes...)
+	}
+
+	return UnionScopes(scopes)
+}
+
+func MissingScopes(granted []string) []string {
+	var missing []string
+
+	for _, scope := range RequiredScopes() {
+		if !contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
+
+func UnionScopes(scopeLists ...[]string) []string {
+	seen := make(map[string]bool)
+
+	var scopes []string
+
+	for _, list := range scopeLists {
+		for _, scope := range list {
+			if scope == "" || seen[scope] {
+				continue
+			}
+
+			seen[scope] = true
+
+			scopes = append(scopes, scope)
+		}
+	}
+
+	sort.Strings(scopes)
+
+	return scopes
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,442 @@
+// Package sde turns a local copy of the EVE static data export into the
+// tables of package data.
+package sde
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"eve-chaperone/eve/data"
+
+	"gopkg.in/yaml.v3"
+)
+
+// metaLevelAttribute is the dogma attribute holding a type's meta level.
+const metaLevelAttribute = 633
+
+// The dogma attributes of wormhole types.
+const (
+	wormholeTargetClassAttribute      = 1381
+	wormholeMaxStableTimeAttribute    = 1382
+	wormholeMaxStableMassAttribute    = 1383
+	wormholeMassRegenerationAttribute = 1384
+	wormholeMaxJumpMassAttribute      = 1385
+)
+
+const (
+	wormholeGroup     = 988
+	effectBeaconGroup = 920
+)
+
+// Categories are the inventory categories whose types are imported: the ones
+// that turn up as ships, weapons and structures on killmails.
+var Categories = []int64{
+	6,  // Ship
+	7,  // Module
+	18, // Drone
+	22, // Deployable
+	23, // Starbase
+	40, // Sovereignty Structures
+	65, // Structure
+	87, // Fighter
+}
+
+var ErrChecksumMismatch = errors.New("the tables do not match their checksums")
+
+// localized is an SDE name, either a plain string or a map of languages.
+type localized string
+
+func (l *localized) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = localized(node.Value)
+
+		return nil
+	}
+
+	var names map[string]string
+
+	err := node.Decode(&names)
+
+	if err != nil {
+		return err
+	}
+
+	*l = localized(names["en"])
+
+	return nil
+}
+
+type sdeType struct {
+	GroupId   int64     `yaml:"groupID"`
+	Name      localized `yaml:"name"`
+	Mass      float64   `yaml:"mass"`
+	Published bool      `yaml:"published"`
+}
+
+type sdeGroup struct {
+	CategoryId int64     `yaml:"categoryID"`
+	Name       localized `yaml:"name"`
+}
+
+type sdeCategory struct {
+	Name localized `yaml:"name"`
+}
+
+type sdeTypeDogma struct {
+	DogmaAttributes []struct {
+		AttributeId int64   `yaml:"attributeID"`
+		Value       float64 `yaml:"value"`
+	} `yaml:"dogmaAttributes"`
+}
+
+// Import reads the SDE unpacked at root, writes the tables to out and returns
+// the contents of their checksum file, which also covers a wormhole statics
+// table already in out. Both the YAML dumps and JSON
+// conversions of them are read; the output only depends on the input.
+func Import(root string, out string) (string, error) {
+	tables, effects, err := readTypes(root)
+
+	if err != nil {
+		return "", err
+	}
+
+	universe, err := readUniverse(root, effects)
+
+	if err != nil {
+		return "", err
+	}
+
+	for name, records := range universe {
+		tables[name] = records
+	}
+
+	err = os.MkdirAll(out, 0755)
+
+	if err != nil {
+		return "", err
+	}
+
+	var sums strings.Builder
+
+	for _, name := range data.Files {
+		b, err := encodeTable(tables[name])
+
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+
+		err = os.WriteFile(filepath.Join(out, name), b, 0644)
+
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256(b), name)
+	}
+
+	// the statics are kept by hand next to the tables, but checked all the same
+	statics, err := os.ReadFile(filepath.Join(out, data.WormholeStaticsFile))
+
+	if err == nil {
+		fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256(statics), data.WormholeStaticsFile)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	err = os.WriteFile(filepath.Join(out, data.ChecksumFile), []byte(sums.String()), 0644)
+
+	if err != nil {
+		return "", err
+	}
+
+	return sums.String(), nil
+}
+
+// Verify checks the tables in dir against its checksum file. Imported tables
+// missing from it and tables without records are rejected as well.
+func Verify(dir string) error {
+	sums, err := os.ReadFile(filepath.Join(dir, data.ChecksumFile))
+
+	if err != nil {
+		return err
+	}
+
+	listed := make(map[string]bool)
+
+	for _, line := range strings.Split(strings.TrimSpace(string(sums)), "\n") {
+		sum, name, ok := strings.Cut(line, "  ")
+
+		if !ok {
+			return fmt.Errorf("%w: malformed line %q", ErrChecksumMismatch, line)
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, name))
+
+		if err != nil {
+			return err
+		}
+
+		actual := sha256.Sum256(b)
+
+		if hex.EncodeToString(actual[:]) != sum {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, name)
+		}
+
+		var records []json.RawMessage
+
+		err = json.Unmarshal(b, &records)
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		if len(records) == 0 {
+			return fmt.Errorf("%w: %s", data.ErrEmptyTable, name)
+		}
+
+		listed[name] = true
+	}
+
+	for _, name := range data.Files {
+		if !listed[name] {
+			return fmt.Errorf("%w: %s is not listed", ErrChecksumMismatch, name)
+		}
+	}
+
+	return nil
+}
+
+// readTypes reads the imported categories and the wormhole types, and returns
+// the effect names of the effect beacons by type ID for readUniverse.
+func readTypes(root string) (map[string]interface{}, map[int64]string, error) {
+	var types map[string]sdeType
+	var groups map[string]sdeGroup
+	var categories map[string]sdeCategory
+	var dogma map[string]sdeTypeDogma
+
+	err := readFile(root, &types, "fsd/types", "fsd/typeIDs")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = readFile(root, &groups, "fsd/groups", "fsd/groupIDs")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = readFile(root, &categories, "fsd/categories", "fsd/categoryIDs")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// meta levels are optional, older exports have no typeDogma
+	err = readFile(root, &dogma, "fsd/typeDogma")
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	imported := make(map[int64]bool)
+
+	var categoryRecords []data.Category
+
+	for key, category := range categories {
+		id, err := strconv.ParseInt(key, 10, 64)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("category %q: %w", key, err)
+		}
+
+		for _, c := range Categories {
+			if c == id {
+				imported[id] = true
+
+				categoryRecords = append(categoryRecords, data.Category{Id: id, Name: string(category.Name)})
+			}
+		}
+	}
+
+	groupCategories := make(map[int64]int64)
+
+	var groupRecords []data.Group
+
+	for key, group := range groups {
+		id, err := strconv.ParseInt(key, 10, 64)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("group %q: %w", key, err)
+		}
+
+		if !imported[group.CategoryId] {
+			continue
+		}
+
+		groupCategories[id] = group.CategoryId
+
+		groupRecords = append(groupRecords, data.Group{Id: id, Name: string(group.Name), CategoryId: group.CategoryId})
+	}
+
+	var typeRecords []data.Type
+	var wormholeRecords []data.Wormhole
+
+	effects := make(map[int64]string)
+
+	for key, t := range types {
+		id, err := strconv.ParseInt(key, 10, 64)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("type %q: %w", key, err)
+		}
+
+		switch t.GroupId {
+		case wormholeGroup:
+			wormholeRecords = append(wormholeRecords, wormholeRecord(id, t, dogma[key]))
+
+			continue
+		case effectBeaconGroup:
+			effects[id] = effectName(string(t.Name))
+
+			continue
+		}
+
+		if !t.Published {
+			continue
+		}
+
+		if _, ok := groupCategories[t.GroupId]; !ok {
+			continue
+		}
+
+		record := data.Type{Id: id, Name: string(t.Name), GroupId: t.GroupId, Mass: t.Mass}
+
+		for _, attribute := range dogma[key].DogmaAttributes {
+			if attribute.AttributeId == metaLevelAttribute {
+				record.MetaLevel = int(attribute.Value)
+			}
+		}
+
+		typeRecords = append(typeRecords, record)
+	}
+
+	sort.Slice(categoryRecords, func(i, j int) bool { return categoryRecords[i].Id < categoryRecords[j].Id })
+	sort.Slice(groupRecords, func(i, j int) bool { return groupRecords[i].Id < groupRecords[j].Id })
+	sort.Slice(typeRecords, func(i, j int) bool { return typeRecords[i].Id < typeRecords[j].Id })
+	sort.Slice(wormholeRecords, func(i, j int) bool { return wormholeRecords[i].Id < wormholeRecords[j].Id })
+
+	return map[string]interface{}{
+		data.TypesFile:      typeRecords,
+		data.GroupsFile:     groupRecords,
+		data.CategoriesFile: categoryRecords,
+		data.WormholesFile:  wormholeRecords,
+	}, effects, nil
+}
+
+func wormholeRecord(id int64, t sdeType, dogma sdeTypeDogma) data.Wormhole {
+	record := data.Wormhole{
+		Id:   id,
+		Name: strings.TrimPrefix(string(t.Name), "Wormhole "),
+	}
+
+	for _, attribute := range dogma.DogmaAttributes {
+		switch attribute.AttributeId {
+		case wormholeTargetClassAttribute:
+			record.TargetClassId = int(attribute.Value)
+		case wormholeMaxStableTimeAttribute:
+			record.MaxStableTime = attribute.Value
+		case wormholeMaxStableMassAttribute:
+			record.MaxStableMass = attribute.Value
+		case wormholeMassRegenerationAttribute:
+			record.MassRegeneration = attribute.Value
+		case wormholeMaxJumpMassAttribute:
+			record.MaxJumpMass = attribute.Value
+		}
+	}
+
+	return record
+}
+
+// effectName turns a beacon like "Wolf Rayet Effect Beacon Class 3" into the
+// name of its effect.
+func effectName(beacon string) string {
+	name, _, _ := strings.Cut(beacon, " Effect Beacon")
+	name, _, _ = strings.Cut(name, " Beacon")
+
+	return strings.Replace(name, "Wolf Rayet", "Wolf-Rayet", 1)
+}
+
+// readFile decodes the first of the named files found under root, trying a
+// .yaml and a .json extension for each.
+func readFile(root string, v interface{}, names ...string) error {
+	for _, name := range names {
+		for _, ext := range []string{".yaml", ".json"} {
+			path := filepath.Join(root, filepath.FromSlash(name)+ext)
+
+			b, err := os.ReadFile(path)
+
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			err = yaml.Unmarshal(b, v)
+
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", fs.ErrNotExist, strings.Join(names, ", "))
+}
+
+// encodeTable writes one record per line so regenerations diff well.
+func encodeTable(records interface{}) ([]byte, error) {
+	b, err := json.Marshal(records)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []json.RawMessage
+
+	err = json.Unmarshal(b, &raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("[")
+
+	for i, record := range raw {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		buf.WriteString("\n")
+		buf.Write(record)
+	}
+
+	buf.WriteString("\n]\n")
+
+	return buf.Bytes(), nil
+}
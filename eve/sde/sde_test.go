@@ -0,0 +1,84 @@
+package sde
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"eve-chaperone/eve/data"
+)
+
+// writeTables writes every table with one record unless tables overrides it,
+// and a checksum file listing every table but skip.
+func writeTables(t *testing.T, tables map[string]string, skip string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	var sums strings.Builder
+
+	for _, name := range data.Files {
+		table, ok := tables[name]
+
+		if !ok {
+			table = "[\n{\"id\":1}\n]\n"
+		}
+
+		err := os.WriteFile(filepath.Join(dir, name), []byte(table), 0644)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if name != skip {
+			fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256([]byte(table)), name)
+		}
+	}
+
+	err := os.WriteFile(filepath.Join(dir, data.ChecksumFile), []byte(sums.String()), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		tables  map[string]string
+		skip    string
+		tamper  string
+		wantErr error
+	}{
+		{name: "valid"},
+		{name: "empty table", tables: map[string]string{data.StargatesFile: "[\n]\n"}, wantErr: data.ErrEmptyTable},
+		{name: "changed table", tamper: data.SystemsFile, wantErr: ErrChecksumMismatch},
+		{name: "unlisted table", skip: data.TypesFile, wantErr: ErrChecksumMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeTables(t, test.tables, test.skip)
+
+			if test.tamper != "" {
+				err := os.WriteFile(filepath.Join(dir, test.tamper), []byte("[\n{\"id\":2}\n]\n"), 0644)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := Verify(dir)
+
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("Verify() = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package sde
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"eve-chaperone/eve/data"
+
+	"gopkg.in/yaml.v3"
+)
+
+type sdeRegion struct {
+	RegionId        int64 `yaml:"regionID"`
+	WormholeClassId int   `yaml:"wormholeClassID"`
+}
+
+type sdeConstellation struct {
+	ConstellationId int64 `yaml:"constellationID"`
+	WormholeClassId int   `yaml:"wormholeClassID"`
+}
+
+type sdeSystem struct {
+	SolarSystemId   int64     `yaml:"solarSystemID"`
+	Security        float64   `yaml:"security"`
+	Center          []float64 `yaml:"center"`
+	WormholeClassId int       `yaml:"wormholeClassID"`
+	SecondarySun    struct {
+		EffectBeaconTypeId int64 `yaml:"effectBeaconTypeID"`
+	} `yaml:"secondarySun"`
+	Stargates map[int64]struct {
+		Destination int64 `yaml:"destination"`
+	} `yaml:"stargates"`
+}
+
+type sdeName struct {
+	ItemId   int64  `yaml:"itemID"`
+	ItemName string `yaml:"itemName"`
+}
+
+// readUniverse walks fsd/universe, where every region folder holds its
+// constellation folders and those hold their systems. Names come from
+// bsd/invNames, falling back to the folder names. effects names the effect
+// beacons by type ID.
+func readUniverse(root string, effects map[int64]string) (map[string]interface{}, error) {
+	var invNames []sdeName
+
+	err := readFile(root, &invNames, "bsd/invNames")
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int64]string, len(invNames))
+
+	for _, name := range invNames {
+		names[name.ItemId] = name.ItemName
+	}
+
+	nameOf := func(id int64, dir string) string {
+		if name, ok := names[id]; ok {
+			return name
+		}
+
+		return filepath.Base(dir)
+	}
+
+	var regions []data.Region
+	var constellations []data.Constellation
+	var systems []data.System
+
+	gateSystems := make(map[int64]int64)
+	gateDestinations := make(map[int64]int64)
+
+	regionFiles, err := filepath.Glob(filepath.Join(root, "fsd", "universe", "*", "*", "region.staticdata"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regionFiles) == 0 {
+		return nil, fmt.Errorf("%w: no regions under %s", fs.ErrNotExist, filepath.Join(root, "fsd", "universe"))
+	}
+
+	for _, regionFile := range regionFiles {
+		regionDir := filepath.Dir(regionFile)
+
+		region := sdeRegion{}
+
+		err = readStaticData(regionFile, &region)
+
+		if err != nil {
+			return nil, err
+		}
+
+		regions = append(regions, data.Region{
+			Id:              region.RegionId,
+			Name:            nameOf(region.RegionId, regionDir),
+			WormholeClassId: region.WormholeClassId,
+		})
+
+		constellationFiles, err := filepath.Glob(filepath.Join(regionDir, "*", "constellation.staticdata"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, constellationFile := range constellationFiles {
+			constellationDir := filepath.Dir(constellationFile)
+
+			constellation := sdeConstellation{}
+
+			err = readStaticData(constellationFile, &constellation)
+
+			if err != nil {
+				return nil, err
+			}
+
+			constellationClass := constellation.WormholeClassId
+
+			if constellationClass == 0 {
+				constellationClass = region.WormholeClassId
+			}
+
+			constellations = append(constellations, data.Constellation{
+				Id:              constellation.ConstellationId,
+				Name:            nameOf(constellation.ConstellationId, constellationDir),
+				RegionId:        region.RegionId,
+				WormholeClassId: constellationClass,
+			})
+
+			systemFiles, err := filepath.Glob(filepath.Join(constellationDir, "*", "solarsystem.staticdata"))
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, systemFile := range systemFiles {
+				system := sdeSystem{}
+
+				err = readStaticData(systemFile, &system)
+
+				if err != nil {
+					return nil, err
+				}
+
+				record := data.System{
+					Id:              system.SolarSystemId,
+					Name:            nameOf(system.SolarSystemId, filepath.Dir(systemFile)),
+					ConstellationId: constellation.ConstellationId,
+					RegionId:        region.RegionId,
+					Security:        system.Security,
+					WormholeClassId: system.WormholeClassId,
+					Effect:          effects[system.SecondarySun.EffectBeaconTypeId],
+				}
+
+				if record.WormholeClassId == 0 {
+					record.WormholeClassId = constellationClass
+				}
+
+				if len(system.Center) == 3 {
+					record.X, record.Y, record.Z = system.Center[0], system.Center[1], system.Center[2]
+				}
+
+				systems = append(systems, record)
+
+				for gateId, gate := range system.Stargates {
+					gateSystems[gateId] = system.SolarSystemId
+					gateDestinations[gateId] = gate.Destination
+				}
+			}
+		}
+	}
+
+	var stargates []data.Stargate
+
+	for gateId, destination := range gateDestinations {
+		stargates = append(stargates, data.Stargate{
+			Id:                  gateId,
+			SystemId:            gateSystems[gateId],
+			DestinationId:       destination,
+			DestinationSystemId: gateSystems[destination],
+		})
+	}
+
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Id < regions[j].Id })
+	sort.Slice(constellations, func(i, j int) bool { return constellations[i].Id < constellations[j].Id })
+	sort.Slice(systems, func(i, j int) bool { return systems[i].Id < systems[j].Id })
+	sort.Slice(stargates, func(i, j int) bool { return stargates[i].Id < stargates[j].Id })
+
+	return map[string]interface{}{
+		data.RegionsFile:        regions,
+		data.ConstellationsFile: constellations,
+		data.SystemsFile:        systems,
+		data.StargatesFile:      stargates,
+	}, nil
+}
+
+func readStaticData(path string, v interface{}) error {
+	b, err := os.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	err = yaml.Unmarshal(b, v)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
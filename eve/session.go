@@ -0,0 +1,183 @@
+package eve
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNoCharacter = errors.New("no character is logged in right now")
+
+var ErrUnknownCharacter = errors.New("the character is not registered")
+
+type Session struct {
+	Character    AccessTokenJWT
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	Scopes       []string
+}
+
+func (s Session) Auth() ESIAuth {
+	return ESIAuth{
+		AccessToken:   s.AccessToken,
+		RefreshToken:  s.RefreshToken,
+		CharacterName: s.Character.Name,
+		CharacterID:   s.Character.CharacterID,
+		ExpiresAt:     s.ExpiresAt,
+		Scopes:        s.Scopes,
+	}
+}
+
+// SessionManager owns the tokens of every registered character and which of
+// them is current. It is safe for concurrent use.
+type SessionManager struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+	current  string
+}
+
+func NewSessionManager() *SessionManager {
+	return &SessionManager{
+		sessions: make(map[string]Session),
+	}
+}
+
+func (m *SessionManager) Put(session Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[session.Character.Name] = session
+}
+
+// Update replaces the session of a registered character and reports whether
+// it was still registered. Unlike Put it never adds a character back that was
+// removed in the meantime.
+func (m *SessionManager) Update(session Session) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[session.Character.Name]; !ok {
+		return false
+	}
+
+	m.sessions[session.Character.Name] = session
+
+	return true
+}
+
+func (m *SessionManager) Get(name string) (Session, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[name]
+
+	return session, ok
+}
+
+func (m *SessionManager) Token(name string) (string, error) {
+	session, ok := m.Get(name)
+
+	if !ok {
+		return "", ErrUnknownCharacter
+	}
+
+	return session.AccessToken, nil
+}
+
+func (m *SessionManager) SetCurrent(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[name]; !ok {
+		return ErrUnknownCharacter
+	}
+
+	m.current = name
+
+	return nil
+}
+
+func (m *SessionManager) Current() (Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[m.current]
+
+	if !ok {
+		return Session{}, ErrNoCharacter
+	}
+
+	return session, nil
+}
+
+func (m *SessionManager) HasCurrent() bool {
+	_, err := m.Current()
+
+	return err == nil
+}
+
+// Characters returns the names of all registered characters in alphabetical
+// order.
+func (m *SessionManager) Characters() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.sessions))
+
+	for name := range m.sessions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// MissingScopes lists every character that was not granted all scopes
+// required by the registered features.
+func (m *SessionManager) MissingScopes() []CharacterScopes {
+	var characters []CharacterScopes
+
+	for _, name := range m.Characters() {
+		session, ok := m.Get(name)
+
+		if !ok {
+			continue
+		}
+
+		missing := MissingScopes(session.Scopes)
+
+		if len(missing) == 0 {
+			continue
+		}
+
+		characters = append(characters, CharacterScopes{
+			CharacterName: name,
+			Granted:       session.Scopes,
+			Missing:       missing,
+		})
+	}
+
+	return characters
+}
+
+func (m *SessionManager) Remove(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, name)
+
+	if m.current == name {
+		m.current = ""
+	}
+}
+
+func (m *SessionManager) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions = make(map[string]Session)
+	m.current = ""
+}
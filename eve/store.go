@@ -0,0 +1,440 @@
+package eve
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	storeFormat  = "eve-chaperone-cache"
+	StoreVersion = 5
+
+	defaultStoreEntries = 50000
+	compactMinBytes     = 1 << 20
+)
+
+// Store is a single-file key/value store kept as an append-only log of JSON
+// lines. The first line stamps the format version; a file with any other
+// version is discarded on open. The log is rewritten with only live records
+// once it grows to twice their size.
+type Store struct {
+	// MaxEntries bounds every bucket; the least recently used records are
+	// evicted first.
+	MaxEntries int
+
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	buckets map[string]*storeBucket
+	size    int64
+	live    int64
+
+	// touched is set when records were read since the last compaction, whose
+	// new order only reaches the file by compacting it
+	touched bool
+}
+
+// storeBucket keeps its entries in access order, most recent first.
+type storeBucket struct {
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type storeEntry struct {
+	key   string
+	value json.RawMessage
+	size  int64
+}
+
+type storeHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+type storeRecord struct {
+	Bucket  string          `json:"b"`
+	Key     string          `json:"k"`
+	Value   json.RawMessage `json:"v,omitempty"`
+	Deleted bool            `json:"d,omitempty"`
+}
+
+func OpenStore(path string) (*Store, error) {
+	s := &Store{
+		MaxEntries: defaultStoreEntries,
+		path:       path,
+		buckets:    make(map[string]*storeBucket),
+	}
+
+	err := s.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.compact()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Store) Get(bucket string, key string, v interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+
+	if !ok {
+		return false, nil
+	}
+
+	element, ok := b.entries[key]
+
+	if !ok {
+		return false, nil
+	}
+
+	b.order.MoveToFront(element)
+
+	s.touched = true
+
+	return true, json.Unmarshal(element.Value.(*storeEntry).value, v)
+}
+
+// Touch marks a record as recently used without reading it. The access order
+// is not logged; it is written back when the log is compacted or the store
+// is closed.
+func (s *Store) Touch(bucket string, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+
+	if !ok {
+		return
+	}
+
+	if element, ok := b.entries[key]; ok {
+		b.order.MoveToFront(element)
+
+		s.touched = true
+	}
+}
+
+// Each calls fn with every record of bucket, least recently used first.
+func (s *Store) Each(bucket string, fn func(key string, value json.RawMessage) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+
+	if !ok {
+		return nil
+	}
+
+	for element := b.order.Back(); element != nil; element = element.Prev() {
+		entry := element.Value.(*storeEntry)
+
+		err := fn(entry.key, entry.value)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) Put(bucket string, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.append(storeRecord{Bucket: bucket, Key: key, Value: value})
+
+	if err != nil {
+		return err
+	}
+
+	s.apply(storeRecord{Bucket: bucket, Key: key, Value: value}, n)
+
+	err = s.evict(bucket)
+
+	if err != nil {
+		return err
+	}
+
+	if s.size > compactMinBytes && s.size > 2*s.live {
+		return s.compact()
+	}
+
+	return nil
+}
+
+func (s *Store) Delete(bucket string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.delete(bucket, key)
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	if s.touched {
+		err := s.compact()
+
+		if err != nil {
+			fmt.Println("could not write the cache access order:", err)
+		}
+	}
+
+	err := s.file.Close()
+
+	s.file = nil
+
+	return err
+}
+
+func (s *Store) delete(bucket string, key string) error {
+	if b, ok := s.buckets[bucket]; !ok || b.entries[key] == nil {
+		return nil
+	}
+
+	n, err := s.append(storeRecord{Bucket: bucket, Key: key, Deleted: true})
+
+	if err != nil {
+		return err
+	}
+
+	s.apply(storeRecord{Bucket: bucket, Key: key, Deleted: true}, n)
+
+	return nil
+}
+
+func (s *Store) evict(bucket string) error {
+	b := s.buckets[bucket]
+
+	for s.MaxEntries > 0 && b.order.Len() > s.MaxEntries {
+		err := s.delete(bucket, b.order.Back().Value.(*storeEntry).key)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) apply(record storeRecord, size int64) {
+	b, ok := s.buckets[record.Bucket]
+
+	if !ok {
+		b = &storeBucket{
+			entries: make(map[string]*list.Element),
+			order:   list.New(),
+		}
+
+		s.buckets[record.Bucket] = b
+	}
+
+	if element, ok := b.entries[record.Key]; ok {
+		s.live -= element.Value.(*storeEntry).size
+
+		b.order.Remove(element)
+
+		delete(b.entries, record.Key)
+	}
+
+	if record.Deleted {
+		return
+	}
+
+	b.entries[record.Key] = b.order.PushFront(&storeEntry{
+		key:   record.Key,
+		value: record.Value,
+		size:  size,
+	})
+
+	s.live += size
+}
+
+func (s *Store) append(record storeRecord) (int64, error) {
+	line, err := json.Marshal(record)
+
+	if err != nil {
+		return 0, err
+	}
+
+	line = append(line, '\n')
+
+	if s.file == nil {
+		return 0, errors.New("the store is closed")
+	}
+
+	_, err = s.file.Write(line)
+
+	if err != nil {
+		return 0, err
+	}
+
+	s.size += int64(len(line))
+
+	return int64(len(line)), nil
+}
+
+// load replays the log. A missing file, a different version or a corrupt
+// header start an empty store; a torn last line is ignored.
+func (s *Store) load() error {
+	file, err := os.Open(s.path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		return nil
+	}
+
+	header := storeHeader{}
+
+	if json.Unmarshal(scanner.Bytes(), &header) != nil || header.Format != storeFormat || header.Version != StoreVersion {
+		fmt.Println("discarding cache with an unknown version:", s.path)
+
+		return nil
+	}
+
+	for scanner.Scan() {
+		record := storeRecord{}
+
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+
+		s.apply(record, int64(len(scanner.Bytes())+1))
+	}
+
+	return scanner.Err()
+}
+
+// compact rewrites the log with only the live records and reopens it for
+// appending.
+func (s *Store) compact() error {
+	for _, b := range s.buckets {
+		for s.MaxEntries > 0 && b.order.Len() > s.MaxEntries {
+			delete(b.entries, b.order.Remove(b.order.Back()).(*storeEntry).key)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+
+	header, err := json.Marshal(storeHeader{Format: storeFormat, Version: StoreVersion})
+
+	if err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	size := int64(len(header) + 1)
+
+	writer.Write(header)
+	writer.WriteByte('\n')
+
+	for bucket, b := range s.buckets {
+		// least recently used first, so eviction order survives a reload
+		for element := b.order.Back(); element != nil; element = element.Prev() {
+			entry := element.Value.(*storeEntry)
+
+			line, err := json.Marshal(storeRecord{Bucket: bucket, Key: entry.key, Value: entry.value})
+
+			if err != nil {
+				tmp.Close()
+
+				return err
+			}
+
+			writer.Write(line)
+			writer.WriteByte('\n')
+
+			entry.size = int64(len(line) + 1)
+			size += entry.size
+		}
+	}
+
+	err = writer.Flush()
+
+	if err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+
+	err = os.Rename(tmp.Name(), s.path)
+
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	s.file = file
+	s.size = size
+	s.live = size
+	s.touched = false
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package eve
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openTestStore(t *testing.T, path string, maxEntries int) *Store {
+	t.Helper()
+
+	store, err := OpenStore(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if maxEntries > 0 {
+		store.MaxEntries = maxEntries
+	}
+
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func storeLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	var lines []string
+
+	scanner := bufio.NewScanner(file)
+
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines
+}
+
+func TestStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	store := openTestStore(t, path, 0)
+
+	for key, value := range map[string]string{"1": "one", "2": "two", "3": "three"} {
+		err := store.Put("names", key, value)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := store.Delete("names", "2")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Close()
+
+	store = openTestStore(t, path, 0)
+
+	var value string
+
+	if ok, err := store.Get("names", "1", &value); !ok || err != nil || value != "one" {
+		t.Errorf(`Get("1") = %q, %v, %v`, value, ok, err)
+	}
+
+	if ok, _ := store.Get("names", "2", &value); ok {
+		t.Error("a deleted record came back after reopening the store")
+	}
+
+	// opening compacts, so only the header and the live records are left
+	if lines := storeLines(t, path); len(lines) != 3 {
+		t.Errorf("the reopened log has %d lines, want 3:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+}
+
+func TestStoreDiscardsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	stale := `{"format":"eve-chaperone-cache","version":1}
+{"b":"names","k":"1","v":"one"}
+`
+
+	err := os.WriteFile(path, []byte(stale), 0600)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := openTestStore(t, path, 0)
+
+	var value string
+
+	if ok, _ := store.Get("names", "1", &value); ok {
+		t.Error("a record of an older store version was kept")
+	}
+
+	lines := storeLines(t, path)
+
+	if len(lines) != 1 || !strings.Contains(lines[0], `"version":`) || strings.Contains(lines[0], `"version":1}`) {
+		t.Errorf("the discarded store was not rewritten with the current header: %q", lines)
+	}
+}
+
+func TestStoreCompactsOverwrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	store := openTestStore(t, path, 0)
+
+	value := strings.Repeat("x", 16*1024)
+
+	for i := 0; i < 200; i++ {
+		err := store.Put("killmails", "1", value)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 200 overwrites append over 3 MiB; compaction keeps the file near the
+	// compaction threshold
+	if info.Size() > 2*compactMinBytes {
+		t.Errorf("the log grew to %d bytes without being compacted", info.Size())
+	}
+
+	var got string
+
+	if ok, err := store.Get("killmails", "1", &got); !ok || err != nil || got != value {
+		t.Errorf("Get() after compaction = %v, %v", ok, err)
+	}
+}
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	store := openTestStore(t, path, 2)
+
+	put := func(key string) {
+		err := store.Put("names", key, key)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	has := func(store *Store, key string) bool {
+		var value string
+
+		ok, _ := store.Get("names", key, &value)
+
+		return ok
+	}
+
+	put("a")
+	put("b")
+
+	store.Touch("names", "a")
+
+	put("c")
+
+	if has(store, "b") || !has(store, "a") || !has(store, "c") {
+		t.Error(`"b" should have been evicted after "a" was touched`)
+	}
+
+	// the log has "a" older than "c", only the access order written back on
+	// close says otherwise
+	store.Touch("names", "a")
+	store.Close()
+
+	store = openTestStore(t, path, 2)
+
+	put("d")
+
+	if has(store, "c") || !has(store, "a") || !has(store, "d") {
+		t.Error(`"c" should have been evicted after reopening, the access order was lost`)
+	}
+}
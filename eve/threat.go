@@ -0,0 +1,324 @@
+package eve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Inventory groups that give a gate camp away.
+const (
+	GroupBattleship       = 27
+	GroupSmartBomb        = 72
+	GroupInterdictor      = 541
+	GroupHeavyInterdictor = 894
+	GroupBlackOps         = 898
+	GroupMarauder         = 900
+)
+
+const DefaultKillWindow = time.Hour
+
+type ThreatLevel string
+
+const (
+	ThreatNone   ThreatLevel = "none"
+	ThreatLow    ThreatLevel = "low"
+	ThreatMedium ThreatLevel = "medium"
+	ThreatHigh   ThreatLevel = "high"
+)
+
+// SystemKillStats are the kills ESI counted in a system over the last hour.
+type SystemKillStats struct {
+	SystemId  int64 `json:"system_id"`
+	ShipKills int   `json:"ship_kills"`
+	PodKills  int   `json:"pod_kills"`
+	NpcKills  int   `json:"npc_kills"`
+}
+
+// SystemThreat scores how dangerous a system on a route looks from its
+// recent kills. Risk runs from 0 to 100.
+type SystemThreat struct {
+	RecentKills       int         `json:"recent_kills"`
+	ShipKills         int         `json:"ship_kills"`
+	PodKills          int         `json:"pod_kills"`
+	Interdictors      bool        `json:"interdictors"`
+	HeavyInterdictors bool        `json:"heavy_interdictors"`
+	Smartbombers      bool        `json:"smartbombers"`
+	Gatecamp          bool        `json:"gatecamp"`
+	Risk              int         `json:"risk"`
+	Level             ThreatLevel `json:"level"`
+	Reasons           []string    `json:"reasons"`
+}
+
+type AssessedHop struct {
+	RouteHop
+	Threat SystemThreat `json:"threat"`
+}
+
+type RouteAssessment struct {
+	Hops    []AssessedHop `json:"hops"`
+	Risk    int           `json:"risk"`
+	Level   ThreatLevel   `json:"level"`
+	Verdict string        `json:"verdict"`
+}
+
+// GetSystemKillStats returns the kills of the last hour in every system that
+// had any.
+func (c *Client) GetSystemKillStats(ctx context.Context) (map[int64]SystemKillStats, error) {
+	res, err := c.getContext(ctx, c.esiRoute("/universe/system_kills/", nil), PriorityNormal)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+
+		return nil, errors.New(res.Status)
+	}
+
+	var systemKills []SystemKillStats
+
+	err = ProcessBody(res.Body, &systemKills)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[int64]SystemKillStats, len(systemKills))
+
+	for _, kills := range systemKills {
+		stats[kills.SystemId] = kills
+	}
+
+	return stats, nil
+}
+
+// AssessRoute scores every hop of a route from the kills ESI counted in the
+// last hour and the most recent zKillboard kills within window, looking up at
+// most RouteWorkers systems at a time. ESI only counts the last hour, so
+// systems without kills there are skipped on zKillboard only when the window
+// is no longer than that.
+func (c *Client) AssessRoute(ctx context.Context, hops []RouteHop, window time.Duration, cache *Cache) (RouteAssessment, error) {
+	if window <= 0 {
+		window = DefaultKillWindow
+	}
+
+	stats, err := c.GetSystemKillStats(ctx)
+
+	if err != nil {
+		return RouteAssessment{}, err
+	}
+
+	since := time.Now().Add(-window)
+
+	threats := make([]SystemThreat, len(hops))
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < max(c.RouteWorkers, 1); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				threats[i] = c.assessHop(ctx, hops[i], stats[hops[i].SystemId], window, since, cache)
+			}
+		}()
+	}
+
+feed:
+	for i := range hops {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(jobs)
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return RouteAssessment{}, err
+	}
+
+	assessment := RouteAssessment{Level: ThreatNone}
+
+	for i, hop := range hops {
+		threat := threats[i]
+
+		assessment.Hops = append(assessment.Hops, AssessedHop{RouteHop: hop, Threat: threat})
+
+		if threat.Risk > assessment.Risk {
+			assessment.Risk = threat.Risk
+			assessment.Level = threat.Level
+		}
+	}
+
+	assessment.Verdict = verdict(assessment)
+
+	return assessment, nil
+}
+
+func (c *Client) assessHop(ctx context.Context, hop RouteHop, kills SystemKillStats, window time.Duration, since time.Time, cache *Cache) SystemThreat {
+	threat := SystemThreat{
+		ShipKills: kills.ShipKills,
+		PodKills:  kills.PodKills,
+	}
+
+	if kills.ShipKills+kills.PodKills > 0 || window > time.Hour {
+		killmails, err := c.GetSystemKills(ctx, int(hop.SystemId), 0, cache)
+
+		if err != nil && !errors.Is(err, ErrNoKillmails) && ctx.Err() == nil {
+			threat.Reasons = append(threat.Reasons, "recent kills unavailable: "+err.Error())
+		}
+
+		c.assessKillmails(ctx, &threat, killmails, since)
+	}
+
+	scoreThreat(&threat)
+
+	return threat
+}
+
+// assessKillmails looks for tackle and smartbombs among the attackers of the
+// kills since a time, and for the same pilots on more than one of them.
+func (c *Client) assessKillmails(ctx context.Context, threat *SystemThreat, killmails []FrontendKillmail, since time.Time) {
+	seen := make(map[int64]bool)
+
+	for _, killmail := range killmails {
+		if killmail.Time.Before(since) {
+			continue
+		}
+
+		threat.RecentKills++
+
+		onKill := make(map[int64]bool)
+
+		for _, attacker := range killmail.Attackers {
+			ship := c.typeGroup(ctx, attacker.ShipTypeId)
+
+			switch ship {
+			case GroupInterdictor:
+				threat.Interdictors = true
+			case GroupHeavyInterdictor:
+				threat.HeavyInterdictors = true
+			case GroupBattleship, GroupBlackOps, GroupMarauder:
+				if c.typeGroup(ctx, attacker.WeaponTypeId) == GroupSmartBomb {
+					threat.Smartbombers = true
+				}
+			}
+
+			if attacker.CharacterId == 0 || onKill[attacker.CharacterId] {
+				continue
+			}
+
+			onKill[attacker.CharacterId] = true
+
+			if seen[attacker.CharacterId] {
+				threat.Gatecamp = true
+			}
+
+			seen[attacker.CharacterId] = true
+		}
+	}
+}
+
+// typeGroup returns the group of a type, or 0 when it cannot be looked up.
+func (c *Client) typeGroup(ctx context.Context, typeId int64) int64 {
+	if typeId == 0 {
+		return 0
+	}
+
+	info, err := c.TypeInfo(ctx, typeId)
+
+	if err != nil {
+		return 0
+	}
+
+	return info.GroupId
+}
+
+func scoreThreat(threat *SystemThreat) {
+	risk := min(threat.RecentKills*15, 45) + min((threat.ShipKills+threat.PodKills)*5, 25)
+
+	if threat.RecentKills > 0 {
+		threat.Reasons = append(threat.Reasons, plural(threat.RecentKills, "recent kill"))
+	}
+
+	if threat.Interdictors {
+		risk += 20
+
+		threat.Reasons = append(threat.Reasons, "interdictors")
+	}
+
+	if threat.HeavyInterdictors {
+		risk += 25
+
+		threat.Reasons = append(threat.Reasons, "heavy interdictors")
+	}
+
+	if threat.Smartbombers {
+		risk += 20
+
+		threat.Reasons = append(threat.Reasons, "smartbombing battleships")
+	}
+
+	if threat.Gatecamp {
+		risk += 20
+
+		threat.Reasons = append(threat.Reasons, "the same pilots on several kills")
+	}
+
+	threat.Risk = min(risk, 100)
+
+	switch {
+	case threat.Risk == 0:
+		threat.Level = ThreatNone
+	case threat.Risk < 25:
+		threat.Level = ThreatLow
+	case threat.Risk < 60:
+		threat.Level = ThreatMedium
+	default:
+		threat.Level = ThreatHigh
+	}
+}
+
+// verdict sums a route up, naming its most dangerous systems.
+func verdict(assessment RouteAssessment) string {
+	if assessment.Level == ThreatNone {
+		return "no recent kills on the route"
+	}
+
+	hops := make([]AssessedHop, 0, len(assessment.Hops))
+
+	for _, hop := range assessment.Hops {
+		if hop.Threat.Level == ThreatMedium || hop.Threat.Level == ThreatHigh {
+			hops = append(hops, hop)
+		}
+	}
+
+	if len(hops) == 0 {
+		return "low risk: only scattered kills on the route"
+	}
+
+	sort.SliceStable(hops, func(i, j int) bool { return hops[i].Threat.Risk > hops[j].Threat.Risk })
+
+	var dangers []string
+
+	for _, hop := range hops[:min(len(hops), 3)] {
+		dangers = append(dangers, fmt.Sprintf("%s (%s)", hop.Name, strings.Join(hop.Threat.Reasons, ", ")))
+	}
+
+	return fmt.Sprintf("%s risk: %s", assessment.Level, strings.Join(dangers, "; "))
+}
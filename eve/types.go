@@ -0,0 +1,142 @@
+package eve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"eve-chaperone/eve/data"
+)
+
+// CategoryShip is the inventory category of every ship type.
+const CategoryShip = 6
+
+type TypeInfo struct {
+	Id         int64   `json:"id"`
+	Name       string  `json:"name"`
+	GroupId    int64   `json:"groupId"`
+	Group      string  `json:"group"`
+	CategoryId int64   `json:"categoryId"`
+	Category   string  `json:"category"`
+	Mass       float64 `json:"mass"`
+	MetaLevel  int     `json:"metaLevel"`
+}
+
+var (
+	typesOnce sync.Once
+	types     map[int64]TypeInfo
+)
+
+// LookupType returns the static data of an inventory type. The tables are
+// read and indexed the first time a type is looked up.
+func LookupType(typeId int64) (TypeInfo, bool) {
+	typesOnce.Do(func() {
+		var err error
+
+		types, err = loadTypes()
+
+		if err != nil {
+			fmt.Println("could not load static type data, ship names come from ESI and threat detection is off:", err)
+		}
+	})
+
+	info, ok := types[typeId]
+
+	return info, ok
+}
+
+// TypeInfo returns the static data of an inventory type, asking ESI for the
+// name, group and mass of types that are not in it. Answers from ESI are kept
+// in the client's cache.
+func (c *Client) TypeInfo(ctx context.Context, typeId int64) (TypeInfo, error) {
+	if info, ok := LookupType(typeId); ok {
+		return info, nil
+	}
+
+	if info, ok := c.Cache.Ship(typeId); ok {
+		return info, nil
+	}
+
+	res, err := c.getContext(ctx, c.esiRoute("/universe/types/"+strconv.FormatInt(typeId, 10)+"/", nil), PriorityLow)
+
+	if err != nil {
+		return TypeInfo{}, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return TypeInfo{}, errors.New("the type could not be fetched: " + res.Status)
+	}
+
+	esiType := data.Type{}
+
+	err = ProcessBody(res.Body, &esiType)
+
+	if err != nil {
+		return TypeInfo{}, err
+	}
+
+	info := TypeInfo{
+		Id:      typeId,
+		Name:    esiType.Name,
+		GroupId: esiType.GroupId,
+		Mass:    esiType.Mass,
+	}
+
+	c.Cache.SetShip(info)
+
+	return info, nil
+}
+
+func loadTypes() (map[int64]TypeInfo, error) {
+	var staticTypes []data.Type
+	var staticGroups []data.Group
+	var staticCategories []data.Category
+
+	for name, v := range map[string]interface{}{
+		data.TypesFile:      &staticTypes,
+		data.GroupsFile:     &staticGroups,
+		data.CategoriesFile: &staticCategories,
+	} {
+		err := data.Load(name, v)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	categories := make(map[int64]string, len(staticCategories))
+
+	for _, category := range staticCategories {
+		categories[category.Id] = category.Name
+	}
+
+	groups := make(map[int64]data.Group, len(staticGroups))
+
+	for _, group := range staticGroups {
+		groups[group.Id] = group
+	}
+
+	types := make(map[int64]TypeInfo, len(staticTypes))
+
+	for _, t := range staticTypes {
+		group := groups[t.GroupId]
+
+		types[t.Id] = TypeInfo{
+			Id:         t.Id,
+			Name:       t.Name,
+			GroupId:    t.GroupId,
+			Group:      group.Name,
+			CategoryId: group.CategoryId,
+			Category:   categories[group.CategoryId],
+			Mass:       t.Mass,
+			MetaLevel:  t.MetaLevel,
+		}
+	}
+
+	return types, nil
+}
@@ -0,0 +1,276 @@
+// Package universe holds the static map of New Eden: regions, constellations,
+// solar systems, the stargates between them and the wormholes of J-space.
+package universe
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"eve-chaperone/eve/data"
+)
+
+type SpaceType string
+
+const (
+	Highsec SpaceType = "highsec"
+	Lowsec  SpaceType = "lowsec"
+	Nullsec SpaceType = "nullsec"
+	JSpace  SpaceType = "j-space"
+	Pochven SpaceType = "pochven"
+	Abyssal SpaceType = "abyssal"
+)
+
+const pochvenRegionId = 10000070
+
+type Region struct {
+	Id            int64  `json:"id"`
+	Name          string `json:"name"`
+	WormholeClass int    `json:"wormhole_class"`
+}
+
+type Constellation struct {
+	Id            int64  `json:"id"`
+	Name          string `json:"name"`
+	RegionId      int64  `json:"region_id"`
+	WormholeClass int    `json:"wormhole_class"`
+}
+
+// System is a solar system with the names of its constellation and region.
+// Its position is in metres.
+type System struct {
+	Id              int64     `json:"id"`
+	Name            string    `json:"name"`
+	ConstellationId int64     `json:"constellation_id"`
+	Constellation   string    `json:"constellation"`
+	RegionId        int64     `json:"region_id"`
+	Region          string    `json:"region"`
+	Security        float64   `json:"security"`
+	RoundedSecurity float64   `json:"rounded_security"`
+	SpaceType       SpaceType `json:"space_type"`
+	WormholeClass   int       `json:"wormhole_class"`
+	Effect          string    `json:"effect"`
+	X               float64   `json:"x"`
+	Y               float64   `json:"y"`
+	Z               float64   `json:"z"`
+}
+
+// Universe indexes the static map. It is read-only once loaded.
+type Universe struct {
+	regions        map[int64]Region
+	constellations map[int64]Constellation
+	systems        map[int64]System
+	names          map[string]int64
+	gates          map[int64][]int64
+	wormholes      map[string]WormholeType
+	statics        map[int64][]string
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultUniverse *Universe
+)
+
+// Default returns the universe loaded from the static data, loading it the
+// first time it is needed. It is empty when the static data cannot be read.
+func Default() *Universe {
+	defaultOnce.Do(func() {
+		var err error
+
+		defaultUniverse, err = Load()
+
+		if err != nil {
+			fmt.Println("could not load the static map:", err)
+
+			defaultUniverse = &Universe{}
+		}
+	})
+
+	return defaultUniverse
+}
+
+func Load() (*Universe, error) {
+	var regions []data.Region
+	var constellations []data.Constellation
+	var systems []data.System
+	var stargates []data.Stargate
+
+	for name, v := range map[string]interface{}{
+		data.RegionsFile:        &regions,
+		data.ConstellationsFile: &constellations,
+		data.SystemsFile:        &systems,
+		data.StargatesFile:      &stargates,
+	} {
+		err := data.Load(name, v)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	u := &Universe{
+		regions:        make(map[int64]Region, len(regions)),
+		constellations: make(map[int64]Constellation, len(constellations)),
+		systems:        make(map[int64]System, len(systems)),
+		names:          make(map[string]int64, len(systems)),
+		gates:          make(map[int64][]int64),
+	}
+
+	for _, region := range regions {
+		u.regions[region.Id] = Region{
+			Id:            region.Id,
+			Name:          region.Name,
+			WormholeClass: region.WormholeClassId,
+		}
+	}
+
+	for _, constellation := range constellations {
+		u.constellations[constellation.Id] = Constellation{
+			Id:            constellation.Id,
+			Name:          constellation.Name,
+			RegionId:      constellation.RegionId,
+			WormholeClass: constellation.WormholeClassId,
+		}
+	}
+
+	for _, system := range systems {
+		u.systems[system.Id] = System{
+			Id:              system.Id,
+			Name:            system.Name,
+			ConstellationId: system.ConstellationId,
+			Constellation:   u.constellations[system.ConstellationId].Name,
+			RegionId:        system.RegionId,
+			Region:          u.regions[system.RegionId].Name,
+			Security:        system.Security,
+			RoundedSecurity: RoundSecurity(system.Security),
+			SpaceType:       SpaceTypeOf(system.RegionId, system.Security),
+			WormholeClass:   system.WormholeClassId,
+			Effect:          system.Effect,
+			X:               system.X,
+			Y:               system.Y,
+			Z:               system.Z,
+		}
+
+		u.names[strings.ToLower(system.Name)] = system.Id
+	}
+
+	for _, gate := range stargates {
+		if gate.DestinationSystemId == 0 {
+			continue
+		}
+
+		u.gates[gate.SystemId] = append(u.gates[gate.SystemId], gate.DestinationSystemId)
+	}
+
+	for id, neighbours := range u.gates {
+		sort.Slice(neighbours, func(i, j int) bool { return neighbours[i] < neighbours[j] })
+
+		u.gates[id] = neighbours
+	}
+
+	err := u.loadWormholes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (u *Universe) System(id int64) (System, bool) {
+	system, ok := u.systems[id]
+
+	return system, ok
+}
+
+// SystemByName looks a system up by its name, ignoring case.
+func (u *Universe) SystemByName(name string) (System, bool) {
+	id, ok := u.names[strings.ToLower(strings.TrimSpace(name))]
+
+	if !ok {
+		return System{}, false
+	}
+
+	return u.System(id)
+}
+
+func (u *Universe) Constellation(id int64) (Constellation, bool) {
+	constellation, ok := u.constellations[id]
+
+	return constellation, ok
+}
+
+func (u *Universe) Region(id int64) (Region, bool) {
+	region, ok := u.regions[id]
+
+	return region, ok
+}
+
+// RegionByName looks a region up by its name, ignoring case.
+func (u *Universe) RegionByName(name string) (Region, bool) {
+	for _, region := range u.regions {
+		if strings.EqualFold(region.Name, strings.TrimSpace(name)) {
+			return region, true
+		}
+	}
+
+	return Region{}, false
+}
+
+// Neighbours returns the systems one stargate jump away from a system.
+func (u *Universe) Neighbours(id int64) []int64 {
+	return u.gates[id]
+}
+
+// Systems returns every system, ordered by ID.
+func (u *Universe) Systems() []System {
+	systems := make([]System, 0, len(u.systems))
+
+	for _, system := range u.systems {
+		systems = append(systems, system)
+	}
+
+	sort.Slice(systems, func(i, j int) bool { return systems[i].Id < systems[j].Id })
+
+	return systems
+}
+
+func (u *Universe) Len() int {
+	return len(u.systems)
+}
+
+// RoundSecurity rounds a true security status the way the game displays it:
+// to one decimal, except that any positive status shows as at least 0.1.
+func RoundSecurity(security float64) float64 {
+	if security > 0 && security < 0.05 {
+		return 0.1
+	}
+
+	return math.Round(security*10) / 10
+}
+
+// SpaceTypeOf tells the kind of space a system with the given region and
+// true security is in.
+func SpaceTypeOf(regionId int64, security float64) SpaceType {
+	switch {
+	case regionId == pochvenRegionId:
+		return Pochven
+	case regionId >= 11000000 && regionId < 12000000:
+		return JSpace
+	case regionId >= 12000000:
+		return Abyssal
+	}
+
+	rounded := RoundSecurity(security)
+
+	switch {
+	case rounded >= 0.5:
+		return Highsec
+	case rounded > 0:
+		return Lowsec
+	}
+
+	return Nullsec
+}
@@ -0,0 +1,168 @@
+package universe
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"eve-chaperone/eve/data"
+)
+
+// Wormhole classes other than C1 to C6, as the SDE numbers them.
+const (
+	ClassHighsec   = 7
+	ClassLowsec    = 8
+	ClassNullsec   = 9
+	ClassThera     = 12
+	ClassShattered = 13
+	ClassPochven   = 25
+)
+
+const theraSystemId = 31000005
+
+// WormholeType is a kind of wormhole, such as B274, and where it leads.
+type WormholeType struct {
+	Id               int64   `json:"id"`
+	Name             string  `json:"name"`
+	DestinationClass int     `json:"destination_class"`
+	Destination      string  `json:"destination"`
+	MaxMass          float64 `json:"max_mass"`
+	MaxJumpMass      float64 `json:"max_jump_mass"`
+	MassRegeneration float64 `json:"mass_regeneration"`
+	LifetimeHours    float64 `json:"lifetime_hours"`
+}
+
+// Wormhole describes a wormhole system: its class, the effect of its
+// phenomenon if it has one, and the static wormholes that always lead out of
+// it. Statics missing from the static data are listed by name only.
+type Wormhole struct {
+	Class   int            `json:"class"`
+	Name    string         `json:"name"`
+	Effect  string         `json:"effect"`
+	Statics []WormholeType `json:"statics"`
+}
+
+// WormholeClassName names a wormhole class the way players do, e.g. C3,
+// Thera or Pochven.
+func WormholeClassName(class int) string {
+	switch {
+	case class >= 1 && class <= 6:
+		return fmt.Sprintf("C%d", class)
+	case class == ClassHighsec:
+		return "Highsec"
+	case class == ClassLowsec:
+		return "Lowsec"
+	case class == ClassNullsec:
+		return "Nullsec"
+	case class == ClassThera:
+		return "Thera"
+	case class == ClassShattered:
+		return "C13"
+	case class >= 14 && class <= 18:
+		return "Drifter"
+	case class == ClassPochven:
+		return "Pochven"
+	}
+
+	return "Unknown"
+}
+
+// Wormhole returns what is known about a system in J-space, Thera or
+// Pochven.
+func (u *Universe) Wormhole(systemId int64) (Wormhole, bool) {
+	system, ok := u.System(systemId)
+
+	if !ok || (system.SpaceType != JSpace && system.SpaceType != Pochven) {
+		return Wormhole{}, false
+	}
+
+	class := system.WormholeClass
+
+	switch {
+	case system.SpaceType == Pochven:
+		class = ClassPochven
+	case system.Id == theraSystemId:
+		class = ClassThera
+	}
+
+	wormhole := Wormhole{
+		Class:  class,
+		Name:   WormholeClassName(class),
+		Effect: system.Effect,
+	}
+
+	for _, name := range u.statics[systemId] {
+		wormholeType, ok := u.WormholeType(name)
+
+		if !ok {
+			wormholeType = WormholeType{Name: name}
+		}
+
+		wormhole.Statics = append(wormhole.Statics, wormholeType)
+	}
+
+	return wormhole, true
+}
+
+// WormholeType looks a wormhole type up by its name, ignoring case.
+func (u *Universe) WormholeType(name string) (WormholeType, bool) {
+	wormholeType, ok := u.wormholes[strings.ToUpper(strings.TrimSpace(name))]
+
+	return wormholeType, ok
+}
+
+// WormholeTypes returns every wormhole type, ordered by name.
+func (u *Universe) WormholeTypes() []WormholeType {
+	types := make([]WormholeType, 0, len(u.wormholes))
+
+	for _, wormholeType := range u.wormholes {
+		types = append(types, wormholeType)
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	return types
+}
+
+func (u *Universe) loadWormholes() error {
+	var wormholes []data.Wormhole
+	var statics []data.WormholeStatic
+
+	// the map is still usable without wormhole data, so empty tables are
+	// only logged; the statics in particular are kept by hand
+	for name, v := range map[string]interface{}{
+		data.WormholesFile:       &wormholes,
+		data.WormholeStaticsFile: &statics,
+	} {
+		err := data.Load(name, v)
+
+		if errors.Is(err, data.ErrEmptyTable) {
+			fmt.Println("wormhole data is missing:", err)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	u.wormholes = make(map[string]WormholeType, len(wormholes))
+	u.statics = make(map[int64][]string, len(statics))
+
+	for _, wormhole := range wormholes {
+		u.wormholes[strings.ToUpper(wormhole.Name)] = WormholeType{
+			Id:               wormhole.Id,
+			Name:             wormhole.Name,
+			DestinationClass: wormhole.TargetClassId,
+			Destination:      WormholeClassName(wormhole.TargetClassId),
+			MaxMass:          wormhole.MaxStableMass,
+			MaxJumpMass:      wormhole.MaxJumpMass,
+			MassRegeneration: wormhole.MassRegeneration,
+			LifetimeHours:    wormhole.MaxStableTime / 60,
+		}
+	}
+
+	for _, static := range statics {
+		u.statics[static.SystemId] = static.Wormholes
+	}
+
+	return nil
+}
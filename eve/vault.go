@@ -0,0 +1,281 @@
+package eve
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const vaultVersion = 1
+
+var ErrVaultPassphrase = errors.New("the vault could not be decrypted with the given passphrase")
+
+// Vault stores the ESI credentials of every registered character.
+type Vault interface {
+	Load() ([]ESIAuth, error)
+	Save(auths []ESIAuth) error
+}
+
+// FileVault keeps credentials in a single AES-GCM encrypted file whose key
+// is derived from a passphrase with scrypt. A plaintext file written by older
+// versions is read once and rewritten encrypted.
+type FileVault struct {
+	path       string
+	passphrase []byte
+
+	mu   sync.Mutex
+	salt []byte
+	key  []byte
+}
+
+type vaultEnvelope struct {
+	Version int    `json:"version"`
+	Salt    string `json:"salt"`
+	Nonce   string `json:"nonce"`
+	Data    string `json:"data"`
+}
+
+func NewFileVault(path string, passphrase string) *FileVault {
+	return &FileVault{
+		path:       path,
+		passphrase: []byte(passphrase),
+	}
+}
+
+func (v *FileVault) Load() ([]ESIAuth, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	data, err := os.ReadFile(v.path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return []ESIAuth{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+
+	if len(data) == 0 {
+		return []ESIAuth{}, nil
+	}
+
+	if data[0] == '[' {
+		return v.migrate(data)
+	}
+
+	envelope := vaultEnvelope{}
+
+	err = json.Unmarshal(data, &envelope)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.Version != vaultVersion {
+		return nil, errors.New("unsupported vault version")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(envelope.Salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := v.deriveKey(salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+
+	if err != nil {
+		return nil, ErrVaultPassphrase
+	}
+
+	var auths []ESIAuth
+
+	err = json.Unmarshal(plaintext, &auths)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return auths, nil
+}
+
+func (v *FileVault) Save(auths []ESIAuth) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.save(auths)
+}
+
+func (v *FileVault) migrate(plaintext []byte) ([]ESIAuth, error) {
+	var auths []ESIAuth
+
+	err := json.Unmarshal(plaintext, &auths)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.save(auths)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return auths, nil
+}
+
+func (v *FileVault) save(auths []ESIAuth) error {
+	if auths == nil {
+		auths = []ESIAuth{}
+	}
+
+	plaintext, err := json.Marshal(auths)
+
+	if err != nil {
+		return err
+	}
+
+	if v.salt == nil {
+		salt := make([]byte, 16)
+
+		_, err = rand.Read(salt)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = v.deriveKey(salt)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	gcm, err := newGCM(v.key)
+
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	_, err = rand.Read(nonce)
+
+	if err != nil {
+		return err
+	}
+
+	envelope := vaultEnvelope{
+		Version: vaultVersion,
+		Salt:    base64.StdEncoding.EncodeToString(v.salt),
+		Nonce:   base64.StdEncoding.EncodeToString(nonce),
+		Data:    base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, nil)),
+	}
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return WritePrivateFile(v.path, data)
+}
+
+func (v *FileVault) deriveKey(salt []byte) ([]byte, error) {
+	if v.key != nil && bytes.Equal(v.salt, salt) {
+		return v.key, nil
+	}
+
+	key, err := scrypt.Key(v.passphrase, salt, 1<<15, 8, 1, 32)
+
+	if err != nil {
+		return nil, err
+	}
+
+	v.salt = salt
+	v.key = key
+
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// WritePrivateFile atomically replaces path with data, readable only by the
+// current user.
+func WritePrivateFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+
+	if err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	err = tmp.Chmod(0600)
+
+	if err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,117 @@
+package eve
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileVault(t *testing.T) {
+	auths := []ESIAuth{
+		{AccessToken: "access", RefreshToken: "refresh", CharacterName: "Pilot One", Scopes: []string{ScopeReadLocation}},
+		{AccessToken: "access 2", RefreshToken: "refresh 2", CharacterName: "Pilot Two"},
+	}
+
+	tests := []struct {
+		name       string
+		stored     string
+		passphrase string
+		want       []ESIAuth
+		wantErr    error
+	}{
+		{name: "missing file", want: []ESIAuth{}},
+		{name: "empty file", stored: "\n", want: []ESIAuth{}},
+		{name: "round trip", stored: "save", passphrase: "secret", want: auths},
+		{name: "wrong passphrase", stored: "save", passphrase: "other", wantErr: ErrVaultPassphrase},
+		{
+			name:   "plaintext migration",
+			stored: `[{"access_token":"access","refresh_token":"refresh","name":"Pilot One","scopes":["esi-location.read_location.v1"]}]`,
+			want:   auths[:1],
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+
+			switch test.stored {
+			case "":
+			case "save":
+				err := NewFileVault(path, "secret").Save(auths)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			default:
+				err := os.WriteFile(path, []byte(test.stored), 0600)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			passphrase := test.passphrase
+
+			if passphrase == "" {
+				passphrase = "secret"
+			}
+
+			got, err := NewFileVault(path, passphrase).Load()
+
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Load() error = %v, want %v", err, test.wantErr)
+			}
+
+			if test.wantErr == nil && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Load() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFileVaultEncryptsMigratedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	plaintext := []byte(`[{"access_token":"access","refresh_token":"refresh","name":"Pilot One"}]`)
+
+	err := os.WriteFile(path, plaintext, 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewFileVault(path, "secret").Load()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(stored, []byte("refresh")) {
+		t.Errorf("the migrated vault still holds the plaintext token: %s", stored)
+	}
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("the vault mode is %v, want 0600", info.Mode().Perm())
+	}
+
+	auths, err := NewFileVault(path, "secret").Load()
+
+	if err != nil || len(auths) != 1 || auths[0].RefreshToken != "refresh" {
+		t.Errorf("reloading the migrated vault = %+v, %v", auths, err)
+	}
+}
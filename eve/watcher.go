@@ -0,0 +1,509 @@
+package eve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Events emitted to the frontend.
+const (
+	EventLocationChanged = "location:changed"
+	EventKillsUpdated    = "kills:updated"
+	EventAuthChanged     = "auth:changed"
+)
+
+const (
+	minLocationPoll     = 2 * time.Second
+	defaultLocationPoll = 5 * time.Second
+	maxLocationPoll     = 30 * time.Second
+
+	DefaultKillsInterval = 30 * time.Second
+)
+
+// LocationEvent is sent when a character moves, docks, undocks or changes
+// ship, and when another character is pinned. The kill feed follows the
+// pinned character; KillsSystemChanged tells when it moves to another system.
+type LocationEvent struct {
+	Character          string           `json:"character"`
+	Location           LocationResponse `json:"location"`
+	Pinned             bool             `json:"pinned"`
+	CharacterChanged   bool             `json:"character_changed"`
+	SystemChanged      bool             `json:"system_changed"`
+	StationChanged     bool             `json:"station_changed"`
+	ShipChanged        bool             `json:"ship_changed"`
+	KillsSystemChanged bool             `json:"kills_system_changed"`
+}
+
+// CharacterLocation is the last location seen for a registered character.
+// UpdatedAt is zero and Location empty until it was fetched once.
+type CharacterLocation struct {
+	Character string           `json:"character"`
+	Location  LocationResponse `json:"location"`
+	Pinned    bool             `json:"pinned"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Error     string           `json:"error"`
+}
+
+// KillsEvent is sent when the recent kills of the current system change.
+type KillsEvent struct {
+	SystemId  int64              `json:"system_id"`
+	Killmails []FrontendKillmail `json:"killmails"`
+}
+
+// AuthEvent describes the registered characters whenever they change.
+// VaultError is set while the vault cannot be opened.
+type AuthEvent struct {
+	Authenticated bool     `json:"authenticated"`
+	Current       string   `json:"current"`
+	Characters    []string `json:"characters"`
+	Revoked       []string `json:"revoked"`
+	VaultError    string   `json:"vault_error"`
+}
+
+// LocationWatcher polls the location of every registered character as often
+// as ESI refreshes it, and the kills of the pinned character's system every
+// KillsInterval. The pinned character is the current one of the sessions.
+type LocationWatcher struct {
+	KillsInterval time.Duration
+
+	// OnLocation and OnKills are called from the watcher's goroutines.
+	OnLocation func(LocationEvent)
+	OnKills    func(KillsEvent)
+
+	client    *Client
+	sessions  *SessionManager
+	refresher *Refresher
+	cache     *Cache
+
+	wake chan struct{}
+
+	mu            sync.Mutex
+	characters    map[string]*trackedLocation
+	pinned        string
+	killsSystem   int64
+	killsCancel   context.CancelFunc
+	lastKillmails []int64
+}
+
+type trackedLocation struct {
+	location  LocationResponse
+	updatedAt time.Time
+	err       error
+	next      time.Time
+}
+
+type locationResult struct {
+	name     string
+	location LocationResponse
+	expires  time.Time
+	err      error
+}
+
+func NewLocationWatcher(client *Client, sessions *SessionManager, refresher *Refresher, cache *Cache) *LocationWatcher {
+	return &LocationWatcher{
+		KillsInterval: DefaultKillsInterval,
+		client:        client,
+		sessions:      sessions,
+		refresher:     refresher,
+		cache:         cache,
+		wake:          make(chan struct{}, 1),
+		characters:    make(map[string]*trackedLocation),
+	}
+}
+
+// Run polls until ctx is cancelled.
+func (w *LocationWatcher) Run(ctx context.Context) {
+	defer w.stopKills()
+
+	force := true
+
+	for {
+		delay := w.poll(ctx, force)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.wake:
+			force = true
+		case <-time.After(delay):
+			force = false
+		}
+	}
+}
+
+// Wake makes the watcher poll every character right away, e.g. after pinning
+// another one.
+func (w *LocationWatcher) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Location returns the last location seen of the pinned character.
+func (w *LocationWatcher) Location() (string, LocationResponse) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if tracked, ok := w.characters[w.pinned]; ok {
+		return w.pinned, tracked.location
+	}
+
+	return w.pinned, LocationResponse{}
+}
+
+// Locations returns one entry per registered character in alphabetical
+// order.
+func (w *LocationWatcher) Locations() []CharacterLocation {
+	pinned := ""
+
+	if session, err := w.sessions.Current(); err == nil {
+		pinned = session.Character.Name
+	}
+
+	names := w.sessions.Characters()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	locations := make([]CharacterLocation, 0, len(names))
+
+	for _, name := range names {
+		location := CharacterLocation{
+			Character: name,
+			Pinned:    name == pinned,
+		}
+
+		if tracked, ok := w.characters[name]; ok {
+			location.Location = tracked.location
+			location.UpdatedAt = tracked.updatedAt
+
+			if tracked.err != nil {
+				location.Error = tracked.err.Error()
+			}
+		}
+
+		locations = append(locations, location)
+	}
+
+	return locations
+}
+
+// poll fetches the locations that are due, or all of them when forced, in
+// parallel and returns how long to wait for the next poll.
+func (w *LocationWatcher) poll(ctx context.Context, force bool) time.Duration {
+	pinned := ""
+
+	if session, err := w.sessions.Current(); err == nil {
+		pinned = session.Character.Name
+	}
+
+	due := w.due(force)
+
+	results := make([]locationResult, len(due))
+
+	var wg sync.WaitGroup
+
+	for i, session := range due {
+		wg.Add(1)
+
+		go func(i int, session Session) {
+			defer wg.Done()
+
+			results[i] = w.fetch(session)
+		}(i, session)
+	}
+
+	wg.Wait()
+
+	now := time.Now()
+
+	var events []LocationEvent
+
+	w.mu.Lock()
+
+	for _, result := range results {
+		tracked, ok := w.characters[result.name]
+
+		// the character was removed while its location was fetched
+		if !ok {
+			continue
+		}
+
+		if result.err != nil {
+			fmt.Println("watching location of "+result.name+":", result.err)
+
+			tracked.err = result.err
+			tracked.next = now.Add(defaultLocationPoll)
+
+			continue
+		}
+
+		event := LocationEvent{
+			Character:      result.name,
+			Location:       result.location,
+			Pinned:         result.name == pinned,
+			SystemChanged:  result.location.SolarSystemId != tracked.location.SolarSystemId,
+			StationChanged: result.location.StationId != tracked.location.StationId || result.location.StructureId != tracked.location.StructureId,
+			ShipChanged:    result.location.ShipItemId != tracked.location.ShipItemId,
+		}
+
+		tracked.location = result.location
+		tracked.updatedAt = now
+		tracked.err = nil
+		tracked.next = now.Add(locationPoll(result.expires))
+
+		if event.SystemChanged || event.StationChanged || event.ShipChanged {
+			events = append(events, event)
+		}
+	}
+
+	pin, ok := w.pin(pinned, events)
+
+	w.mu.Unlock()
+
+	if ok {
+		events = append(events, pin)
+	}
+
+	if pinned == "" {
+		w.stopKills()
+	}
+
+	for _, event := range events {
+		if w.OnLocation != nil {
+			w.OnLocation(event)
+		}
+
+		if event.Pinned && event.KillsSystemChanged {
+			w.watchKills(ctx, int64(event.Location.SolarSystemId))
+		}
+	}
+
+	return w.delay()
+}
+
+// due forgets removed characters and returns the sessions whose location
+// should be fetched now.
+func (w *LocationWatcher) due(force bool) []Session {
+	names := w.sessions.Characters()
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for name := range w.characters {
+		if !slices.Contains(names, name) {
+			delete(w.characters, name)
+		}
+	}
+
+	var due []Session
+
+	for _, name := range names {
+		tracked, ok := w.characters[name]
+
+		if !ok {
+			tracked = &trackedLocation{}
+
+			w.characters[name] = tracked
+		}
+
+		if !force && now.Before(tracked.next) {
+			continue
+		}
+
+		session, ok := w.sessions.Get(name)
+
+		// characters restored from vaults of older versions have no ID
+		// until their first refresh, and revoked ones have to log in again
+		if !ok || session.Character.CharacterID == 0 || w.refresher.IsRevoked(name) {
+			tracked.next = now.Add(defaultLocationPoll)
+
+			continue
+		}
+
+		due = append(due, session)
+	}
+
+	return due
+}
+
+// fetch gets the location of one character, refreshing its token once if ESI
+// rejects it.
+func (w *LocationWatcher) fetch(session Session) locationResult {
+	result := locationResult{name: session.Character.Name}
+
+	location, err := w.client.GetCharacterLocation(session)
+
+	if errors.Is(err, ErrForbidden) {
+		session, err = w.refresher.Refresh(result.name)
+
+		if err == nil {
+			location, err = w.client.GetCharacterLocation(session)
+		}
+	}
+
+	if err != nil {
+		result.err = err
+
+		return result
+	}
+
+	result.location = location
+	result.expires = w.client.LocationExpires(session)
+
+	return result
+}
+
+// pin marks the pinned character's event, adding one when the pin moved to a
+// character that did not change itself. Its KillsSystemChanged tells whether
+// the kill feed has to move. It must be called with mu held.
+func (w *LocationWatcher) pin(pinned string, events []LocationEvent) (LocationEvent, bool) {
+	tracked, ok := w.characters[pinned]
+
+	// nothing to show until the pinned character's location is known
+	if !ok || tracked.updatedAt.IsZero() {
+		if pinned == "" {
+			w.pinned = ""
+		}
+
+		return LocationEvent{}, false
+	}
+
+	changed := pinned != w.pinned
+
+	w.pinned = pinned
+
+	for i := range events {
+		if events[i].Character == pinned {
+			events[i].CharacterChanged = changed
+			events[i].KillsSystemChanged = int64(events[i].Location.SolarSystemId) != w.killsSystem
+
+			return LocationEvent{}, false
+		}
+	}
+
+	if !changed {
+		return LocationEvent{}, false
+	}
+
+	return LocationEvent{
+		Character:          pinned,
+		Location:           tracked.location,
+		Pinned:             true,
+		CharacterChanged:   true,
+		KillsSystemChanged: int64(tracked.location.SolarSystemId) != w.killsSystem,
+	}, true
+}
+
+// delay returns how long to wait until the next character is due.
+func (w *LocationWatcher) delay() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var next time.Time
+
+	for _, tracked := range w.characters {
+		if next.IsZero() || tracked.next.Before(next) {
+			next = tracked.next
+		}
+	}
+
+	if next.IsZero() {
+		return defaultLocationPoll
+	}
+
+	return min(max(time.Until(next), minLocationPoll), maxLocationPoll)
+}
+
+// locationPoll returns how long to wait before fetching a location again.
+func locationPoll(expires time.Time) time.Duration {
+	if expires.IsZero() {
+		return defaultLocationPoll
+	}
+
+	return min(max(time.Until(expires), minLocationPoll), maxLocationPoll)
+}
+
+// watchKills starts polling the kills of a system, stopping the poll of the
+// previous one.
+func (w *LocationWatcher) watchKills(ctx context.Context, systemId int64) {
+	w.stopKills()
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	w.mu.Lock()
+	w.killsSystem = systemId
+	w.killsCancel = cancel
+	w.lastKillmails = nil
+	w.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(w.KillsInterval)
+		defer ticker.Stop()
+
+		for {
+			w.pollKills(ctx, systemId)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (w *LocationWatcher) pollKills(ctx context.Context, systemId int64) {
+	killmails, err := w.client.GetSystemKills(ctx, int(systemId), 0, w.cache)
+
+	if err != nil && !errors.Is(err, ErrNoKillmails) {
+		if ctx.Err() == nil {
+			fmt.Println("watching kills:", err)
+		}
+
+		return
+	}
+
+	ids := make([]int64, 0, len(killmails))
+
+	for _, killmail := range killmails {
+		ids = append(ids, killmail.KillmailId)
+	}
+
+	w.mu.Lock()
+
+	// the feed moved on to another system while these kills were fetched
+	if ctx.Err() != nil || systemId != w.killsSystem {
+		w.mu.Unlock()
+
+		return
+	}
+
+	changed := w.lastKillmails == nil || !slices.Equal(ids, w.lastKillmails)
+
+	w.lastKillmails = ids
+
+	w.mu.Unlock()
+
+	if changed && w.OnKills != nil {
+		w.OnKills(KillsEvent{SystemId: systemId, Killmails: killmails})
+	}
+}
+
+func (w *LocationWatcher) stopKills() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.killsCancel != nil {
+		w.killsCancel()
+	}
+
+	w.killsCancel = nil
+	w.killsSystem = 0
+}
@@ -1,23 +1,16 @@
 package eve
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
+	"sync"
 	"time"
-
-	"github.com/buger/jsonparser"
 )
 
-type Ship struct {
-	Name struct {
-		En string `json:"en"`
-	} `json:"name"`
-	Id string `json:"id"`
-}
+var ErrNoKillmails = errors.New("no killmails found")
 
 type ZKillboardSystemIDResponse struct {
 	KillmailID int `json:"killmail_id"`
@@ -32,6 +25,7 @@ type Killmail struct {
 		AllianceId    int `json:"alliance_id"`
 		CorporationId int `json:"corporation_id"`
 		ShipTypeId    int `json:"ship_type_id"`
+		WeaponTypeId  int `json:"weapon_type_id"`
 	} `json:"attackers"`
 	Victim struct {
 		CharacterId   int `json:"character_id"`
@@ -47,20 +41,43 @@ type FrontendKillmail struct {
 	Attackers    []FrontendKillmailAttackers `json:"attackers"`
 	KillmailId   int64                       `json:"killmailId"`
 	KillmailTime string                      `json:"killmail_time"`
+	Time         time.Time                   `json:"time"`
+	Summary      KillmailSummary             `json:"summary"`
 }
 
 type FrontendKillmailAttackers struct {
-	Character   string `json:"character_id"`
-	Alliance    string `json:"alliance_id"`
-	Corporation string `json:"corporation_id"`
-	ShipType    string `json:"ship_type_id"`
+	Character     string `json:"character_id"`
+	Alliance      string `json:"alliance_id"`
+	Corporation   string `json:"corporation_id"`
+	ShipType      string `json:"ship_type_id"`
+	CharacterId   int64  `json:"characterId"`
+	AllianceId    int64  `json:"allianceId"`
+	CorporationId int64  `json:"corporationId"`
+	ShipTypeId    int64  `json:"shipTypeId"`
+	WeaponTypeId  int64  `json:"weaponTypeId"`
 }
 
 type FrontendKillmailVictim struct {
-	Character   string `json:"character_id"`
-	Alliance    string `json:"alliance_id"`
-	Corporation string `json:"corporation_id"`
-	ShipType    string `json:"ship_type_id"`
+	Character     string `json:"character_id"`
+	Alliance      string `json:"alliance_id"`
+	Corporation   string `json:"corporation_id"`
+	ShipType      string `json:"ship_type_id"`
+	CharacterId   int64  `json:"characterId"`
+	AllianceId    int64  `json:"allianceId"`
+	CorporationId int64  `json:"corporationId"`
+	ShipTypeId    int64  `json:"shipTypeId"`
+}
+
+// KillmailSummary tells at a glance who was on a kill. Attackers without an
+// alliance are grouped by their corporation instead.
+type KillmailSummary struct {
+	Attackers    int    `json:"attackers"`
+	Corporations int    `json:"corporations"`
+	Alliances    int    `json:"alliances"`
+	TopGroupId   int64  `json:"topGroupId"`
+	TopGroup     string `json:"topGroup"`
+	TopCount     int    `json:"topCount"`
+	Text         string `json:"text"`
 }
 
 type ESIResourceResponse struct {
@@ -69,7 +86,10 @@ type ESIResourceResponse struct {
 
 //TODO: use cache and mutate killmail to contain human readable info
 
-func GetSystemKills(systemID int, pageNumber int, cache Cache) ([]FrontendKillmail, error) {
+// GetSystemKills returns a page of the most recent kills in a system. The
+// killmails of the page are fetched concurrently; cancelling ctx abandons the
+// page.
+func (c *Client) GetSystemKills(ctx context.Context, systemID int, pageNumber int, cache *Cache) ([]FrontendKillmail, error) {
 	fmt.Println(systemID)
 
 	requestCount := 0
@@ -78,12 +98,18 @@ func GetSystemKills(systemID int, pageNumber int, cache Cache) ([]FrontendKillma
 
 	var zKillboardSystemIDResponses []ZKillboardSystemIDResponse
 
-	res, err := http.Get("https://zkillboard.com/api/kills/systemID/" + strconv.Itoa(systemID) + "/")
+	res, err := c.getContext(ctx, c.zkillRoute("/kills/systemID/"+strconv.Itoa(systemID)+"/"), PriorityNormal)
 
 	if err != nil {
 		return frontendKillmails, err
 	}
 
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return frontendKillmails, errors.New("the kills could not be fetched: " + res.Status)
+	}
+
 	requestCount += 1
 
 	err = ProcessBody(res.Body, &zKillboardSystemIDResponses)
@@ -107,44 +133,60 @@ func GetSystemKills(systemID int, pageNumber int, cache Cache) ([]FrontendKillma
 	}
 
 	if len(kills) == 0 {
-		return frontendKillmails, errors.New("no killmails found")
+		return frontendKillmails, ErrNoKillmails
 	}
 
 	page := kills[pageNumber]
 
 	fmt.Println("page created")
 
-	for _, kill := range page {
-		if cache.Killmails[int64(kill.KillmailID)].KillmailTime != "" {
-			frontendKillmails = append(frontendKillmails, cache.Killmails[int64(kill.KillmailID)])
+	var fetched []ZKillboardSystemIDResponse
 
+	for _, kill := range page {
+		if _, ok := cache.Killmail(int64(kill.KillmailID)); ok {
 			fmt.Println("found kill in cache")
 
 			continue
 		}
 
-		killmailRes, err := http.Get(
-			fmt.Sprintf(
-				BaseESIRoute+"/killmails/%s/%s",
-				strconv.Itoa(kill.KillmailID),
-				kill.ZKB.Hash,
-			),
-		)
+		fetched = append(fetched, kill)
+	}
 
-		if err != nil {
-			return frontendKillmails, err
-		}
+	fetched, killmails, err := c.fetchKillmails(ctx, fetched)
 
-		requestCount += 1
+	if err != nil {
+		return frontendKillmails, err
+	}
 
-		killmail := Killmail{}
+	requestCount += len(killmails)
 
-		err = ProcessBody(killmailRes.Body, &killmail)
+	var ids []UniverseName
 
-		if err != nil {
-			return frontendKillmails, err
+	addIds := func(characterId int, corporationId int, allianceId int) {
+		ids = append(ids,
+			UniverseName{Id: int64(characterId), Category: "character"},
+			UniverseName{Id: int64(corporationId), Category: "corporation"},
+			UniverseName{Id: int64(allianceId), Category: "alliance"},
+		)
+	}
+
+	for _, killmail := range killmails {
+		addIds(killmail.Victim.CharacterId, killmail.Victim.CorporationId, killmail.Victim.AllianceId)
+
+		for _, attacker := range killmail.Attackers {
+			addIds(attacker.CharacterId, attacker.CorporationId, attacker.AllianceId)
 		}
+	}
 
+	names, err := c.ResolveNames(ctx, ids, cache)
+
+	if err != nil {
+		return frontendKillmails, err
+	}
+
+	requestCount += 1
+
+	for i, killmail := range killmails {
 		t, err := time.Parse(time.RFC3339, killmail.KillmailTime)
 
 		if err != nil {
@@ -153,111 +195,258 @@ func GetSystemKills(systemID int, pageNumber int, cache Cache) ([]FrontendKillma
 
 		frontendKillmail := FrontendKillmail{
 			KillmailTime: t.Format("2006-01-02 15:04 AM"),
+			Time:         t,
 		}
 
 		for _, attacker := range killmail.Attackers {
-			var errors []error
+			shipName, err := c.GetShipName(ctx, int64(attacker.ShipTypeId))
+
+			if err != nil {
+				return frontendKillmails, err
+			}
 
-			shipName, err := GetShipName(int64(attacker.ShipTypeId))
-			errors = append(errors, err)
+			frontendKillmail.Attackers = append(frontendKillmail.Attackers, FrontendKillmailAttackers{
+				ShipType:      shipName,
+				Character:     names[int64(attacker.CharacterId)],
+				Corporation:   names[int64(attacker.CorporationId)],
+				Alliance:      names[int64(attacker.AllianceId)],
+				CharacterId:   int64(attacker.CharacterId),
+				CorporationId: int64(attacker.CorporationId),
+				AllianceId:    int64(attacker.AllianceId),
+				ShipTypeId:    int64(attacker.ShipTypeId),
+				WeaponTypeId:  int64(attacker.WeaponTypeId),
+			})
+		}
 
-			characterName, err := GetCharacterName(int64(attacker.CharacterId))
-			errors = append(errors, err)
+		shipName, err := c.GetShipName(ctx, int64(killmail.Victim.ShipTypeId))
 
-			/*
-				corporationName, err := GetCorporationName(int64(attacker.CorporationId))
-				errors = append(errors, err)
+		if err != nil {
+			return frontendKillmails, err
+		}
+
+		frontendKillmailVictim := FrontendKillmailVictim{
+			ShipType:      shipName,
+			Character:     names[int64(killmail.Victim.CharacterId)],
+			Corporation:   names[int64(killmail.Victim.CorporationId)],
+			Alliance:      names[int64(killmail.Victim.AllianceId)],
+			CharacterId:   int64(killmail.Victim.CharacterId),
+			CorporationId: int64(killmail.Victim.CorporationId),
+			AllianceId:    int64(killmail.Victim.AllianceId),
+			ShipTypeId:    int64(killmail.Victim.ShipTypeId),
+		}
 
-				allianceName, err := GetAllianceName(int64(attacker.AllianceId))
-				errors = append(errors, err)
-			*/
+		frontendKillmail.Victim = frontendKillmailVictim
+		frontendKillmail.KillmailId = int64(fetched[i].KillmailID)
+		frontendKillmail.Summary = SummarizeAttackers(frontendKillmail.Attackers)
 
-			for _, err := range errors {
-				requestCount += 1
+		cache.SetKillmail(frontendKillmail)
+	}
 
-				if err != nil {
-					return frontendKillmails, err
-				}
-			}
+	for _, kill := range page {
+		killmail, ok := cache.Killmail(int64(kill.KillmailID))
 
-			frontendKillmail.Attackers = append(frontendKillmail.Attackers, FrontendKillmailAttackers{
-				ShipType:    shipName,
-				Character:   characterName,
-				Corporation: "",
-				Alliance:    "",
-			})
+		if !ok {
+			continue
 		}
 
-		var errors []error
+		frontendKillmails = append(frontendKillmails, killmail)
+	}
 
-		shipName, err := GetShipName(int64(killmail.Victim.ShipTypeId))
-		errors = append(errors, err)
+	fmt.Println(requestCount)
 
-		characterName, err := GetCharacterName(int64(killmail.Victim.CharacterId))
-		errors = append(errors, err)
+	return frontendKillmails, nil
+}
 
-		/*
-			corporationName, err := GetCorporationName(int64(killmail.Victim.CorporationId))
-			errors = append(errors, err)
+// SummarizeAttackers counts the attackers of a kill and the corporations and
+// alliances they flew for, and picks the group with the most attackers, e.g.
+// "12 attackers from 3 alliances, top: Goonswarm Federation (7)".
+func SummarizeAttackers(attackers []FrontendKillmailAttackers) KillmailSummary {
+	summary := KillmailSummary{Attackers: len(attackers)}
 
-			allianceName, err := GetAllianceName(int64(killmail.Victim.AllianceId))
-			errors = append(errors, err)
-		*/
+	corporations := make(map[int64]bool)
+	alliances := make(map[int64]bool)
+	counts := make(map[int64]int)
+	groups := make(map[int64]string)
 
-		for _, err := range errors {
-			requestCount += 1
-			if err != nil {
-				return frontendKillmails, err
-			}
+	for _, attacker := range attackers {
+		if attacker.CorporationId != 0 {
+			corporations[attacker.CorporationId] = true
 		}
 
-		frontendKillmailVictim := FrontendKillmailVictim{
-			ShipType:    shipName,
-			Character:   characterName,
-			Corporation: "",
-			Alliance:    "",
+		if attacker.AllianceId != 0 {
+			alliances[attacker.AllianceId] = true
 		}
 
-		frontendKillmail.Victim = frontendKillmailVictim
-		frontendKillmail.KillmailId = int64(kill.KillmailID)
+		// NPCs and structures have neither, so they are not a group
+		group, name := attacker.AllianceId, attacker.Alliance
 
-		frontendKillmails = append(frontendKillmails, frontendKillmail)
+		if group == 0 {
+			group, name = attacker.CorporationId, attacker.Corporation
+		}
 
-		cache.Killmails[int64(kill.KillmailID)] = frontendKillmail
+		if group == 0 {
+			continue
+		}
+
+		counts[group]++
+		groups[group] = name
 	}
 
-	fmt.Println(requestCount)
+	for group, count := range counts {
+		if count > summary.TopCount || (count == summary.TopCount && group < summary.TopGroupId) {
+			summary.TopGroupId = group
+			summary.TopCount = count
+		}
+	}
 
-	return frontendKillmails, nil
+	summary.TopGroup = groups[summary.TopGroupId]
+	summary.Corporations = len(corporations)
+	summary.Alliances = len(alliances)
+
+	summary.Text = plural(summary.Attackers, "attacker")
+
+	if summary.Alliances > 0 {
+		summary.Text += " from " + plural(summary.Alliances, "alliance")
+	} else if summary.Corporations > 0 {
+		summary.Text += " from " + plural(summary.Corporations, "corporation")
+	}
+
+	if summary.TopGroupId != 0 {
+		top := summary.TopGroup
+
+		if top == "" {
+			top = "Unknown"
+		}
+
+		summary.Text += fmt.Sprintf(", top: %s (%d)", top, summary.TopCount)
+	}
+
+	return summary
 }
 
-func GetCharacterName(characterId int64) (string, error) {
-	esiResourceResponse := ESIResourceResponse{}
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+
+	return strconv.Itoa(n) + " " + noun + "s"
+}
+
+// fetchKillmails fetches the ESI killmails of kills with at most
+// KillmailWorkers requests in flight, keeping the order of kills. Killmails
+// that cannot be fetched are left out along with their kill; only when none
+// could be fetched is an error returned.
+func (c *Client) fetchKillmails(ctx context.Context, kills []ZKillboardSystemIDResponse) ([]ZKillboardSystemIDResponse, []Killmail, error) {
+	killmails := make([]Killmail, len(kills))
+	failed := make([]error, len(kills))
+
+	workers := max(c.KillmailWorkers, 1)
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				killmails[i], failed[i] = c.GetKillmail(ctx, kills[i])
+			}
+		}()
+	}
+
+feed:
+	for i := range kills {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(jobs)
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var fetchedKills []ZKillboardSystemIDResponse
+	var fetched []Killmail
+	var firstErr error
+
+	for i, err := range failed {
+		if err != nil {
+			fmt.Println("skipping killmail", kills[i].KillmailID, err)
+
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
 
-	res, err := http.Get(BaseESIRoute + "/characters/" + strconv.Itoa(int(characterId)))
+		fetchedKills = append(fetchedKills, kills[i])
+		fetched = append(fetched, killmails[i])
+	}
+
+	if len(fetched) == 0 && firstErr != nil {
+		return nil, nil, firstErr
+	}
+
+	return fetchedKills, fetched, nil
+}
+
+func (c *Client) GetKillmail(ctx context.Context, kill ZKillboardSystemIDResponse) (Killmail, error) {
+	res, err := c.getContext(
+		ctx,
+		c.esiRoute(
+			fmt.Sprintf("/killmails/%s/%s/", strconv.Itoa(kill.KillmailID), kill.ZKB.Hash),
+			nil,
+		),
+		PriorityLow,
+	)
 
 	if err != nil {
-		return "", err
+		return Killmail{}, err
 	}
 
-	err = ProcessBody(res.Body, &esiResourceResponse)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Killmail{}, errors.New("the killmail could not be fetched: " + res.Status)
+	}
+
+	killmail := Killmail{}
+
+	err = ProcessBody(res.Body, &killmail)
 
 	if err != nil {
-		return "", err
+		return Killmail{}, err
 	}
 
-	return esiResourceResponse.Name, nil
+	return killmail, nil
 }
 
-func GetAllianceName(allianceId int64) (string, error) {
+func (c *Client) GetCharacterName(characterId int64) (string, error) {
 	esiResourceResponse := ESIResourceResponse{}
 
-	res, err := http.Get(BaseESIRoute + "/alliances/" + strconv.Itoa(int(allianceId)))
+	res, err := c.get(c.esiRoute("/characters/"+strconv.Itoa(int(characterId))+"/", nil), PriorityLow)
 
 	if err != nil {
 		return "", err
 	}
 
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New("the character name could not be fetched: " + res.Status)
+	}
+
 	err = ProcessBody(res.Body, &esiResourceResponse)
 
 	if err != nil {
@@ -267,15 +456,21 @@ func GetAllianceName(allianceId int64) (string, error) {
 	return esiResourceResponse.Name, nil
 }
 
-func GetCorporationName(corporationId int64) (string, error) {
+func (c *Client) GetAllianceName(allianceId int64) (string, error) {
 	esiResourceResponse := ESIResourceResponse{}
 
-	res, err := http.Get(BaseESIRoute + "/corporations/" + strconv.Itoa(int(corporationId)))
+	res, err := c.get(c.esiRoute("/alliances/"+strconv.Itoa(int(allianceId))+"/", nil), PriorityLow)
 
 	if err != nil {
 		return "", err
 	}
 
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New("the alliance name could not be fetched: " + res.Status)
+	}
+
 	err = ProcessBody(res.Body, &esiResourceResponse)
 
 	if err != nil {
@@ -285,26 +480,49 @@ func GetCorporationName(corporationId int64) (string, error) {
 	return esiResourceResponse.Name, nil
 }
 
-func GetShipName(shipId int64) (string, error) {
-	data, err := os.ReadFile("./ships.json")
+func (c *Client) GetCorporationName(corporationId int64) (string, error) {
+	esiResourceResponse := ESIResourceResponse{}
+
+	res, err := c.get(c.esiRoute("/corporations/"+strconv.Itoa(int(corporationId))+"/", nil), PriorityLow)
 
 	if err != nil {
 		return "", err
 	}
 
-	s, _, _, err := jsonparser.Get(data, strconv.Itoa(int(shipId)))
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New("the corporation name could not be fetched: " + res.Status)
+	}
+
+	err = ProcessBody(res.Body, &esiResourceResponse)
 
 	if err != nil {
+		return "", err
+	}
+
+	return esiResourceResponse.Name, nil
+}
+
+// GetShipName returns the name of a ship type from the static data, or from
+// ESI for types that are not in it, e.g. ships released after the export.
+// "Unknown" is returned when neither knows the type.
+func (c *Client) GetShipName(ctx context.Context, shipId int64) (string, error) {
+	if shipId == 0 {
 		return "Unknown", nil
 	}
 
-	ship := Ship{}
+	info, err := c.TypeInfo(ctx, shipId)
 
-	err = json.Unmarshal(s, &ship)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
 
 	if err != nil {
+		fmt.Println("could not look up type", shipId, err)
+
 		return "Unknown", nil
 	}
 
-	return ship.Name.En, nil
+	return info.Name, nil
 }
@@ -1,12 +1,43 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {eve} from '../models';
+import {universe} from '../models';
+
+export function AssessRoute(arg1:string,arg2:string,arg3:eve.RouteOptions):Promise<eve.RouteAssessment>;
+
+export function CancelAuth():Promise<void>;
 
 export function CheckAuth():Promise<boolean>;
 
+export function CheckJump(arg1:string,arg2:string,arg3:eve.JumpOptions):Promise<eve.JumpCheck>;
+
+export function ExportJumpLog(arg1:string,arg2:string):Promise<string>;
+
+export function GetAllLocations():Promise<Array<eve.CharacterLocation>>;
+
+export function GetAuthState():Promise<eve.AuthEvent>;
+
+export function GetCacheStats():Promise<{[key: string]: eve.CacheStats}>;
+
+export function GetCharactersMissingScopes():Promise<Array<eve.CharacterScopes>>;
+
+export function GetErrorBudget():Promise<eve.ErrorBudget>;
+
+export function GetHTTPCacheStats():Promise<eve.HTTPCacheStats>;
+
+export function GetLastJumps(arg1:string,arg2:number):Promise<Array<eve.JumpLogEntry>>;
+
 export function GetLocation():Promise<eve.LocationResponse>;
 
-export function GetRegisteredCharacters():Promise<Array<eve.ESIAuth>>;
+export function GetRegisteredCharacters():Promise<Array<string>>;
+
+export function GetRevokedCharacters():Promise<Array<string>>;
+
+export function GetTimePerSystem(arg1:string,arg2:number):Promise<Array<eve.SystemTime>>;
+
+export function GetTodaysRoute(arg1:string):Promise<Array<eve.JumpLogEntry>>;
+
+export function GetWormholeTypes():Promise<Array<universe.WormholeType>>;
 
 export function GetZkill(arg1:number):Promise<Array<eve.FrontendKillmail>>;
 
@@ -14,4 +45,16 @@ export function LogOut():Promise<void>;
 
 export function OpenAuth():Promise<void>;
 
+export function PinCharacter(arg1:string):Promise<void>;
+
+export function PlanJumps(arg1:string,arg2:string,arg3:eve.JumpOptions):Promise<eve.JumpPlan>;
+
+export function PlanRoute(arg1:string,arg2:string,arg3:eve.RouteOptions):Promise<Array<eve.RouteHop>>;
+
+export function RemoveCharacter(arg1:string):Promise<void>;
+
 export function SwitchCurrentCharacter(arg1:string):Promise<void>;
+
+export function UnlockVault(arg1:string):Promise<void>;
+
+export function UpgradeScopes():Promise<void>;
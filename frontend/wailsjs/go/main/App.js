@@ -2,10 +2,54 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AssessRoute(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AssessRoute'](arg1, arg2, arg3);
+}
+
+export function CancelAuth() {
+  return window['go']['main']['App']['CancelAuth']();
+}
+
 export function CheckAuth() {
   return window['go']['main']['App']['CheckAuth']();
 }
 
+export function CheckJump(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CheckJump'](arg1, arg2, arg3);
+}
+
+export function ExportJumpLog(arg1, arg2) {
+  return window['go']['main']['App']['ExportJumpLog'](arg1, arg2);
+}
+
+export function GetAllLocations() {
+  return window['go']['main']['App']['GetAllLocations']();
+}
+
+export function GetAuthState() {
+  return window['go']['main']['App']['GetAuthState']();
+}
+
+export function GetCacheStats() {
+  return window['go']['main']['App']['GetCacheStats']();
+}
+
+export function GetCharactersMissingScopes() {
+  return window['go']['main']['App']['GetCharactersMissingScopes']();
+}
+
+export function GetErrorBudget() {
+  return window['go']['main']['App']['GetErrorBudget']();
+}
+
+export function GetHTTPCacheStats() {
+  return window['go']['main']['App']['GetHTTPCacheStats']();
+}
+
+export function GetLastJumps(arg1, arg2) {
+  return window['go']['main']['App']['GetLastJumps'](arg1, arg2);
+}
+
 export function GetLocation() {
   return window['go']['main']['App']['GetLocation']();
 }
@@ -14,6 +58,22 @@ export function GetRegisteredCharacters() {
   return window['go']['main']['App']['GetRegisteredCharacters']();
 }
 
+export function GetRevokedCharacters() {
+  return window['go']['main']['App']['GetRevokedCharacters']();
+}
+
+export function GetTimePerSystem(arg1, arg2) {
+  return window['go']['main']['App']['GetTimePerSystem'](arg1, arg2);
+}
+
+export function GetTodaysRoute(arg1) {
+  return window['go']['main']['App']['GetTodaysRoute'](arg1);
+}
+
+export function GetWormholeTypes() {
+  return window['go']['main']['App']['GetWormholeTypes']();
+}
+
 export function GetZkill(arg1) {
   return window['go']['main']['App']['GetZkill'](arg1);
 }
@@ -26,6 +86,30 @@ export function OpenAuth() {
   return window['go']['main']['App']['OpenAuth']();
 }
 
+export function PinCharacter(arg1) {
+  return window['go']['main']['App']['PinCharacter'](arg1);
+}
+
+export function PlanJumps(arg1, arg2, arg3) {
+  return window['go']['main']['App']['PlanJumps'](arg1, arg2, arg3);
+}
+
+export function PlanRoute(arg1, arg2, arg3) {
+  return window['go']['main']['App']['PlanRoute'](arg1, arg2, arg3);
+}
+
+export function RemoveCharacter(arg1) {
+  return window['go']['main']['App']['RemoveCharacter'](arg1);
+}
+
 export function SwitchCurrentCharacter(arg1) {
   return window['go']['main']['App']['SwitchCurrentCharacter'](arg1);
 }
+
+export function UnlockVault(arg1) {
+  return window['go']['main']['App']['UnlockVault'](arg1);
+}
+
+export function UpgradeScopes() {
+  return window['go']['main']['App']['UpgradeScopes']();
+}
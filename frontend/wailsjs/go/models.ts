@@ -1,26 +1,15 @@
 export namespace eve {
 	
-	export class ESIAuth {
-	    access_token: string;
-	    refresh_token: string;
-	    name: string;
-	
-	    static createFrom(source: any = {}) {
-	        return new ESIAuth(source);
-	    }
-	
-	    constructor(source: any = {}) {
-	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.access_token = source["access_token"];
-	        this.refresh_token = source["refresh_token"];
-	        this.name = source["name"];
-	    }
-	}
 	export class FrontendKillmailAttackers {
 	    character_id: string;
 	    alliance_id: string;
 	    corporation_id: string;
 	    ship_type_id: string;
+	    characterId: number;
+	    allianceId: number;
+	    corporationId: number;
+	    shipTypeId: number;
+	    weaponTypeId: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new FrontendKillmailAttackers(source);
@@ -32,6 +21,11 @@ export namespace eve {
 	        this.alliance_id = source["alliance_id"];
 	        this.corporation_id = source["corporation_id"];
 	        this.ship_type_id = source["ship_type_id"];
+	        this.characterId = source["characterId"];
+	        this.allianceId = source["allianceId"];
+	        this.corporationId = source["corporationId"];
+	        this.shipTypeId = source["shipTypeId"];
+	        this.weaponTypeId = source["weaponTypeId"];
 	    }
 	}
 	export class FrontendKillmailVictim {
@@ -39,6 +33,10 @@ export namespace eve {
 	    alliance_id: string;
 	    corporation_id: string;
 	    ship_type_id: string;
+	    characterId: number;
+	    allianceId: number;
+	    corporationId: number;
+	    shipTypeId: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new FrontendKillmailVictim(source);
@@ -50,6 +48,10 @@ export namespace eve {
 	        this.alliance_id = source["alliance_id"];
 	        this.corporation_id = source["corporation_id"];
 	        this.ship_type_id = source["ship_type_id"];
+	        this.characterId = source["characterId"];
+	        this.allianceId = source["allianceId"];
+	        this.corporationId = source["corporationId"];
+	        this.shipTypeId = source["shipTypeId"];
 	    }
 	}
 	export class FrontendKillmail {
@@ -57,6 +59,8 @@ export namespace eve {
 	    attackers: FrontendKillmailAttackers[];
 	    killmailId: number;
 	    killmail_time: string;
+	    time: any;
+	    summary: KillmailSummary;
 	
 	    static createFrom(source: any = {}) {
 	        return new FrontendKillmail(source);
@@ -68,6 +72,8 @@ export namespace eve {
 	        this.attackers = this.convertValues(source["attackers"], FrontendKillmailAttackers);
 	        this.killmailId = source["killmailId"];
 	        this.killmail_time = source["killmail_time"];
+	        this.time = source["time"];
+	        this.summary = this.convertValues(source["summary"], KillmailSummary);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -95,6 +101,18 @@ export namespace eve {
 	    solar_system_id: number;
 	    station_id: number;
 	    structure_id: number;
+	    security_status: number;
+	    rounded_security: number;
+	    constellation_id: number;
+	    constellation: string;
+	    region_id: number;
+	    region: string;
+	    space_type: string;
+	    ship_item_id: number;
+	    ship_type_id: number;
+	    ship_type: string;
+	    ship_name: string;
+	    wormhole?: universe.Wormhole;
 	
 	    static createFrom(source: any = {}) {
 	        return new LocationResponse(source);
@@ -106,8 +124,651 @@ export namespace eve {
 	        this.solar_system_id = source["solar_system_id"];
 	        this.station_id = source["station_id"];
 	        this.structure_id = source["structure_id"];
+	        this.security_status = source["security_status"];
+	        this.rounded_security = source["rounded_security"];
+	        this.constellation_id = source["constellation_id"];
+	        this.constellation = source["constellation"];
+	        this.region_id = source["region_id"];
+	        this.region = source["region"];
+	        this.space_type = source["space_type"];
+	        this.ship_item_id = source["ship_item_id"];
+	        this.ship_type_id = source["ship_type_id"];
+	        this.ship_type = source["ship_type"];
+	        this.ship_name = source["ship_name"];
+	        this.wormhole = this.convertValues(source["wormhole"], universe.Wormhole);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CharacterScopes {
+	    name: string;
+	    granted: string[];
+	    missing: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CharacterScopes(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.granted = source["granted"];
+	        this.missing = source["missing"];
+	    }
+	}
+	export class ErrorBudget {
+	    remain: number;
+	    reset_at: any;
+	    blocked_until: any;
+	    shed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ErrorBudget(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.remain = source["remain"];
+	        this.reset_at = source["reset_at"];
+	        this.blocked_until = source["blocked_until"];
+	        this.shed = source["shed"];
+	    }
+	}
+	export class HTTPCacheStats {
+	    hits: number;
+	    revalidated: number;
+	    misses: number;
+	    entries: number;
+	    hit_ratio: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HTTPCacheStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hits = source["hits"];
+	        this.revalidated = source["revalidated"];
+	        this.misses = source["misses"];
+	        this.entries = source["entries"];
+	        this.hit_ratio = source["hit_ratio"];
+	    }
+	}
+	export class CacheStats {
+	    hits: number;
+	    misses: number;
+	    evictions: number;
+	    entries: number;
+	    bytes: number;
+	    hit_ratio: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hits = source["hits"];
+	        this.misses = source["misses"];
+	        this.evictions = source["evictions"];
+	        this.entries = source["entries"];
+	        this.bytes = source["bytes"];
+	        this.hit_ratio = source["hit_ratio"];
+	    }
+	}
+	export class KillmailSummary {
+	    attackers: number;
+	    corporations: number;
+	    alliances: number;
+	    topGroupId: number;
+	    topGroup: string;
+	    topCount: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KillmailSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.attackers = source["attackers"];
+	        this.corporations = source["corporations"];
+	        this.alliances = source["alliances"];
+	        this.topGroupId = source["topGroupId"];
+	        this.topGroup = source["topGroup"];
+	        this.topCount = source["topCount"];
+	        this.text = source["text"];
+	    }
+	}
+	export class RouteHop {
+	    system_id: number;
+	    name: string;
+	    security: number;
+	    rounded_security: number;
+	    space_type: string;
+	    region: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RouteHop(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.system_id = source["system_id"];
+	        this.name = source["name"];
+	        this.security = source["security"];
+	        this.rounded_security = source["rounded_security"];
+	        this.space_type = source["space_type"];
+	        this.region = source["region"];
 	    }
 	}
+	export class RouteOptions {
+	    preference: string;
+	    avoid: string[];
+	    kill_window_minutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RouteOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.preference = source["preference"];
+	        this.avoid = source["avoid"];
+	        this.kill_window_minutes = source["kill_window_minutes"];
+	    }
+	}
+	export class SystemThreat {
+	    recent_kills: number;
+	    ship_kills: number;
+	    pod_kills: number;
+	    interdictors: boolean;
+	    heavy_interdictors: boolean;
+	    smartbombers: boolean;
+	    gatecamp: boolean;
+	    risk: number;
+	    level: string;
+	    reasons: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SystemThreat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recent_kills = source["recent_kills"];
+	        this.ship_kills = source["ship_kills"];
+	        this.pod_kills = source["pod_kills"];
+	        this.interdictors = source["interdictors"];
+	        this.heavy_interdictors = source["heavy_interdictors"];
+	        this.smartbombers = source["smartbombers"];
+	        this.gatecamp = source["gatecamp"];
+	        this.risk = source["risk"];
+	        this.level = source["level"];
+	        this.reasons = source["reasons"];
+	    }
+	}
+	export class AssessedHop {
+	    system_id: number;
+	    name: string;
+	    security: number;
+	    rounded_security: number;
+	    space_type: string;
+	    region: string;
+	    threat: SystemThreat;
+	
+	    static createFrom(source: any = {}) {
+	        return new AssessedHop(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.system_id = source["system_id"];
+	        this.name = source["name"];
+	        this.security = source["security"];
+	        this.rounded_security = source["rounded_security"];
+	        this.space_type = source["space_type"];
+	        this.region = source["region"];
+	        this.threat = this.convertValues(source["threat"], SystemThreat);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RouteAssessment {
+	    hops: AssessedHop[];
+	    risk: number;
+	    level: string;
+	    verdict: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RouteAssessment(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hops = this.convertValues(source["hops"], AssessedHop);
+	        this.risk = source["risk"];
+	        this.level = source["level"];
+	        this.verdict = source["verdict"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class JumpOptions {
+	    ship: string;
+	    calibration_level: number;
+	    fatigue_minutes: number;
+	    avoid: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new JumpOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ship = source["ship"];
+	        this.calibration_level = source["calibration_level"];
+	        this.fatigue_minutes = source["fatigue_minutes"];
+	        this.avoid = source["avoid"];
+	    }
+	}
+	export class JumpCheck {
+	    light_years: number;
+	    range: number;
+	    in_range: boolean;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumpCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.light_years = source["light_years"];
+	        this.range = source["range"];
+	        this.in_range = source["in_range"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class JumpLeg {
+	    from: RouteHop;
+	    to: RouteHop;
+	    light_years: number;
+	    effective_light_years: number;
+	    depart_minutes: number;
+	    fatigue_minutes: number;
+	    activation_minutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumpLeg(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = this.convertValues(source["from"], RouteHop);
+	        this.to = this.convertValues(source["to"], RouteHop);
+	        this.light_years = source["light_years"];
+	        this.effective_light_years = source["effective_light_years"];
+	        this.depart_minutes = source["depart_minutes"];
+	        this.fatigue_minutes = source["fatigue_minutes"];
+	        this.activation_minutes = source["activation_minutes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class JumpPlan {
+	    ship: string;
+	    range: number;
+	    legs: JumpLeg[];
+	    light_years: number;
+	    total_minutes: number;
+	    final_fatigue_minutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumpPlan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ship = source["ship"];
+	        this.range = source["range"];
+	        this.legs = this.convertValues(source["legs"], JumpLeg);
+	        this.light_years = source["light_years"];
+	        this.total_minutes = source["total_minutes"];
+	        this.final_fatigue_minutes = source["final_fatigue_minutes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LocationEvent {
+	    character: string;
+	    location: LocationResponse;
+	    pinned: boolean;
+	    character_changed: boolean;
+	    system_changed: boolean;
+	    station_changed: boolean;
+	    ship_changed: boolean;
+	    kills_system_changed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LocationEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.character = source["character"];
+	        this.location = this.convertValues(source["location"], LocationResponse);
+	        this.pinned = source["pinned"];
+	        this.character_changed = source["character_changed"];
+	        this.system_changed = source["system_changed"];
+	        this.station_changed = source["station_changed"];
+	        this.ship_changed = source["ship_changed"];
+	        this.kills_system_changed = source["kills_system_changed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class KillsEvent {
+	    system_id: number;
+	    killmails: FrontendKillmail[];
+	
+	    static createFrom(source: any = {}) {
+	        return new KillsEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.system_id = source["system_id"];
+	        this.killmails = this.convertValues(source["killmails"], FrontendKillmail);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AuthEvent {
+	    authenticated: boolean;
+	    current: string;
+	    characters: string[];
+	    revoked: string[];
+	    vault_error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuthEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.authenticated = source["authenticated"];
+	        this.current = source["current"];
+	        this.characters = source["characters"];
+	        this.revoked = source["revoked"];
+	        this.vault_error = source["vault_error"];
+	    }
+	}
+	export class CharacterLocation {
+	    character: string;
+	    location: LocationResponse;
+	    pinned: boolean;
+	    updated_at: any;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CharacterLocation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.character = source["character"];
+	        this.location = this.convertValues(source["location"], LocationResponse);
+	        this.pinned = source["pinned"];
+	        this.updated_at = source["updated_at"];
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class JumpLogEntry {
+	    time: any;
+	    character: string;
+	    kind: string;
+	    system_id: number;
+	    system: string;
+	    from_system_id?: number;
+	    from_system?: string;
+	    station_id?: number;
+	    structure_id?: number;
+	    ship_type?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumpLogEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.character = source["character"];
+	        this.kind = source["kind"];
+	        this.system_id = source["system_id"];
+	        this.system = source["system"];
+	        this.from_system_id = source["from_system_id"];
+	        this.from_system = source["from_system"];
+	        this.station_id = source["station_id"];
+	        this.structure_id = source["structure_id"];
+	        this.ship_type = source["ship_type"];
+	    }
+	}
+	export class SystemTime {
+	    system_id: number;
+	    system: string;
+	    minutes: number;
+	    visits: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SystemTime(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.system_id = source["system_id"];
+	        this.system = source["system"];
+	        this.minutes = source["minutes"];
+	        this.visits = source["visits"];
+	    }
+	}
+
+}
+
+export namespace universe {
+	
+	export class WormholeType {
+	    id: number;
+	    name: string;
+	    destination_class: number;
+	    destination: string;
+	    max_mass: number;
+	    max_jump_mass: number;
+	    mass_regeneration: number;
+	    lifetime_hours: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WormholeType(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.destination_class = source["destination_class"];
+	        this.destination = source["destination"];
+	        this.max_mass = source["max_mass"];
+	        this.max_jump_mass = source["max_jump_mass"];
+	        this.mass_regeneration = source["mass_regeneration"];
+	        this.lifetime_hours = source["lifetime_hours"];
+	    }
+	}
+	export class Wormhole {
+	    class: number;
+	    name: string;
+	    effect: string;
+	    statics: WormholeType[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Wormhole(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.class = source["class"];
+	        this.name = source["name"];
+	        this.effect = source["effect"];
+	        this.statics = this.convertValues(source["statics"], WormholeType);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
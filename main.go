@@ -3,6 +3,7 @@ package main
 import (
 	"embed"
 	"log"
+	"os"
 
 	"github.com/joho/godotenv"
 	"github.com/wailsapp/wails/v2"
@@ -14,6 +15,10 @@ import (
 var assets embed.FS
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "sde" {
+		os.Exit(runSDE(os.Args[2:]))
+	}
+
 	err := godotenv.Load()
 
 	if err != nil {
@@ -33,6 +38,7 @@ func main() {
 		},
 		OnStartup:   app.startup,
 		OnDomReady:  app.OnDomReady,
+		OnShutdown:  app.shutdown,
 		AlwaysOnTop: true,
 		Frameless:   false,
 		Bind: []interface{}{
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"eve-chaperone/eve/sde"
+)
+
+const sdeUsage = `usage:
+  eve-chaperone sde import [-out dir] <sde path>
+  eve-chaperone sde verify [dir]
+
+import reads the unpacked static data export at <sde path> and writes the
+static data tables to -out, by default the sde folder of the config directory
+where they take precedence over the built-in ones. Use -out eve/data to
+regenerate the built-in tables.
+
+verify checks the tables in dir, by default the same folder, against their
+checksums.`
+
+// runSDE runs the sde subcommand and returns the exit code.
+func runSDE(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, sdeUsage)
+
+		return 2
+	}
+
+	defaultOut := "sde"
+
+	if chaperonePath, err := configDir(); err == nil {
+		defaultOut = filepath.Join(chaperonePath, "sde")
+	}
+
+	switch args[0] {
+	case "import":
+		flags := flag.NewFlagSet("sde import", flag.ContinueOnError)
+		out := flags.String("out", defaultOut, "directory to write the tables to")
+
+		if flags.Parse(args[1:]) != nil || flags.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, sdeUsage)
+
+			return 2
+		}
+
+		sums, err := sde.Import(flags.Arg(0), *out)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+
+			return 1
+		}
+
+		fmt.Print(sums)
+
+		return 0
+	case "verify":
+		dir := defaultOut
+
+		if len(args) > 1 {
+			dir = args[1]
+		}
+
+		err := sde.Verify(dir)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+
+			return 1
+		}
+
+		fmt.Println("ok")
+
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, sdeUsage)
+
+	return 2
+}